@@ -0,0 +1,183 @@
+package main
+
+import (
+	"encoding/json"
+	"io"
+	"math"
+	"math/rand"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	oteltrace "go.opentelemetry.io/otel/trace"
+)
+
+var accessLogRecords = prometheus.NewCounterVec(
+	prometheus.CounterOpts{
+		Name: "app1_access_log_records_total",
+		Help: "Access log records by result (written, sampled_out, failed)",
+	},
+	[]string{"result"},
+)
+
+func init() {
+	prometheus.MustRegister(accessLogRecords)
+}
+
+// accessRecord es el esquema estable del access log. Los campos solo se
+// agregan, nunca se renombran, para no romper las consultas en Loki.
+type accessRecord struct {
+	Timestamp  string  `json:"timestamp"`
+	LogType    string  `json:"log_type"`
+	Service    string  `json:"service"`
+	InstanceID string  `json:"instance_id"`
+	Method     string  `json:"method"`
+	Route      string  `json:"route"`
+	Path       string  `json:"path"`
+	Status     int     `json:"status"`
+	Bytes      int64   `json:"bytes"`
+	DurationMs float64 `json:"duration_ms"`
+	TraceID    string  `json:"trace_id"`
+	ClientIP   string  `json:"client_ip"`
+	Country    string  `json:"country"`
+	UserAgent  string  `json:"user_agent"`
+}
+
+// accessLogger escribe el access log en un sink separado del log de
+// aplicación (stdout). Las respuestas 4xx/5xx siempre se registran; el
+// resto se muestrea con ACCESS_LOG_SAMPLE.
+type accessLogger struct {
+	sample atomic.Uint64 // float64 en bits, recargable
+
+	mu  sync.Mutex
+	out io.Writer
+}
+
+var accessLog = newAccessLogger()
+
+func newAccessLogger() *accessLogger {
+	l := &accessLogger{out: os.Stderr}
+	l.reloadSample()
+
+	// ACCESS_LOG_SINK: stderr (default), stdout, none o file:/ruta
+	switch sink := os.Getenv("ACCESS_LOG_SINK"); {
+	case sink == "" || sink == "stderr":
+	case sink == "stdout":
+		l.out = os.Stdout
+	case sink == "none":
+		l.out = nil
+	case strings.HasPrefix(sink, "file:"):
+		f, err := os.OpenFile(strings.TrimPrefix(sink, "file:"), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+		if err != nil {
+			logMessage("warn", "Error opening access log file, using stderr: "+err.Error(), "")
+		} else {
+			l.out = f
+		}
+	default:
+		logMessage("warn", "Unknown ACCESS_LOG_SINK "+sink+", using stderr", "")
+	}
+
+	return l
+}
+
+func (l *accessLogger) reloadSample() {
+	sample := 1.0
+	if v, err := strconv.ParseFloat(getConfig("ACCESS_LOG_SAMPLE"), 64); err == nil && v >= 0 && v <= 1 {
+		sample = v
+	}
+	l.sample.Store(math.Float64bits(sample))
+}
+
+func (l *accessLogger) sampleRatio() float64 {
+	return math.Float64frombits(l.sample.Load())
+}
+
+func (l *accessLogger) write(record accessRecord) {
+	if l.out == nil {
+		return
+	}
+	if sample := l.sampleRatio(); record.Status < 400 && sample < 1 && rand.Float64() >= sample {
+		accessLogRecords.WithLabelValues("sampled_out").Inc()
+		return
+	}
+
+	// Mismas reglas de redacción que el log de aplicación (client_ip, etc.)
+	fields := map[string]interface{}{}
+	raw, _ := json.Marshal(record)
+	json.Unmarshal(raw, &fields)
+	telemetryRedactor.logFields(fields)
+
+	line, err := json.Marshal(fields)
+	if err != nil {
+		accessLogRecords.WithLabelValues("failed").Inc()
+		return
+	}
+
+	l.mu.Lock()
+	_, err = l.out.Write(append(line, '\n'))
+	l.mu.Unlock()
+
+	if err != nil {
+		accessLogRecords.WithLabelValues("failed").Inc()
+		return
+	}
+	accessLogRecords.WithLabelValues("written").Inc()
+}
+
+// statusRecorder captura el código y los bytes de la respuesta.
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+	bytes  int64
+}
+
+func (s *statusRecorder) WriteHeader(code int) {
+	s.status = code
+	s.ResponseWriter.WriteHeader(code)
+}
+
+func (s *statusRecorder) Write(b []byte) (int, error) {
+	n, err := s.ResponseWriter.Write(b)
+	s.bytes += int64(n)
+	return n, err
+}
+
+// routeTemplate devuelve el patrón del mux que atiende la request, para
+// agrupar por ruta sin abrir cardinalidad con paths arbitrarios.
+func routeTemplate(mux *http.ServeMux, r *http.Request) string {
+	if _, pattern := mux.Handler(r); pattern != "" {
+		return pattern
+	}
+	return "other"
+}
+
+func accessLogMiddleware(mux *http.ServeMux, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		rec := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+
+		next.ServeHTTP(rec, r)
+
+		accessLog.write(accessRecord{
+			Timestamp:  start.UTC().Format(time.RFC3339Nano),
+			LogType:    "access",
+			Service:    "app1",
+			InstanceID: instanceID,
+			Method:     r.Method,
+			Route:      routeTemplate(mux, r),
+			Path:       r.URL.Path,
+			Status:     rec.status,
+			Bytes:      rec.bytes,
+			DurationMs: float64(time.Since(start).Microseconds()) / 1000,
+			TraceID:    oteltrace.SpanFromContext(r.Context()).SpanContext().TraceID().String(),
+			ClientIP:   clientIP(r),
+			Country:    countryFromContext(r.Context()),
+			UserAgent:  r.UserAgent(),
+		})
+	})
+}
@@ -0,0 +1,137 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	oteltrace "go.opentelemetry.io/otel/trace"
+)
+
+var alertsReceived = prometheus.NewCounterVec(
+	prometheus.CounterOpts{
+		Name: "app1_alerts_received_total",
+		Help: "Alerts received from the Alertmanager webhook",
+	},
+	[]string{"alertname", "severity", "status"},
+)
+
+func init() {
+	prometheus.MustRegister(alertsReceived)
+}
+
+// El webhook no se autentica, así que los labels de alertsReceived se
+// acotan: severidades conocidas y a lo sumo maxAlertNames nombres distintos,
+// el resto cuenta como "other".
+const maxAlertNames = 50
+
+var alertSeverities = map[string]bool{"critical": true, "warning": true, "info": true}
+
+var alertNames = struct {
+	sync.Mutex
+	seen map[string]bool
+}{seen: make(map[string]bool)}
+
+func alertNameLabel(name string) string {
+	alertNames.Lock()
+	defer alertNames.Unlock()
+
+	if !alertNames.seen[name] {
+		if name == "" || len(alertNames.seen) >= maxAlertNames {
+			return "other"
+		}
+		alertNames.seen[name] = true
+	}
+	return name
+}
+
+func alertSeverityLabel(severity string) string {
+	if alertSeverities[severity] {
+		return severity
+	}
+	return "other"
+}
+
+// alertmanagerPayload es el cuerpo del webhook de Alertmanager (versión 4).
+type alertmanagerPayload struct {
+	Version           string            `json:"version"`
+	GroupKey          string            `json:"groupKey"`
+	Status            string            `json:"status"`
+	Receiver          string            `json:"receiver"`
+	GroupLabels       map[string]string `json:"groupLabels"`
+	CommonLabels      map[string]string `json:"commonLabels"`
+	CommonAnnotations map[string]string `json:"commonAnnotations"`
+	ExternalURL       string            `json:"externalURL"`
+	Alerts            []struct {
+		Status       string            `json:"status"`
+		Labels       map[string]string `json:"labels"`
+		Annotations  map[string]string `json:"annotations"`
+		StartsAt     time.Time         `json:"startsAt"`
+		EndsAt       time.Time         `json:"endsAt"`
+		GeneratorURL string            `json:"generatorURL"`
+		Fingerprint  string            `json:"fingerprint"`
+	} `json:"alerts"`
+}
+
+// alertWebhookHandler recibe las notificaciones de Alertmanager y deja una
+// línea de log estructurada por alerta, para poder correlacionarlas en Loki
+// con el resto de los logs del lab.
+func alertWebhookHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", "POST")
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var payload alertmanagerPayload
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1<<20)).Decode(&payload); err != nil {
+		http.Error(w, "invalid JSON body", http.StatusBadRequest)
+		return
+	}
+
+	// Alertmanager solo envía estos dos estados
+	for _, alert := range payload.Alerts {
+		if alert.Status != "firing" && alert.Status != "resolved" {
+			http.Error(w, "invalid alert status", http.StatusBadRequest)
+			return
+		}
+	}
+
+	traceID := oteltrace.SpanFromContext(r.Context()).SpanContext().TraceID().String()
+
+	for _, alert := range payload.Alerts {
+		alertname := alert.Labels["alertname"]
+		severity := alert.Labels["severity"]
+		alertsReceived.WithLabelValues(alertNameLabel(alertname), alertSeverityLabel(severity), alert.Status).Inc()
+
+		level := "warn"
+		if alert.Status == "resolved" {
+			level = "info"
+		}
+
+		fields := map[string]interface{}{
+			"alert_name":        alertname,
+			"alert_status":      alert.Status,
+			"alert_severity":    severity,
+			"alert_fingerprint": alert.Fingerprint,
+			"alert_labels":      alert.Labels,
+			"alert_annotations": alert.Annotations,
+			"alert_starts_at":   alert.StartsAt,
+			"alert_receiver":    payload.Receiver,
+			"alert_group_key":   payload.GroupKey,
+		}
+		if !alert.EndsAt.IsZero() {
+			fields["alert_ends_at"] = alert.EndsAt
+		}
+		if alert.GeneratorURL != "" {
+			fields["alert_generator_url"] = alert.GeneratorURL
+		}
+
+		logWithFields(level, fmt.Sprintf("Alert %s is %s", alertname, alert.Status), traceID, fields)
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,219 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"net/http"
+	"os"
+	"sort"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Señales pensadas para HPA/KEDA. El prefijo app1_autoscaling_ y sus labels
+// se mantienen estables aunque cambien las demás métricas del servicio.
+var (
+	inFlightRequests = prometheus.NewGaugeFunc(
+		prometheus.GaugeOpts{
+			Name: "app1_autoscaling_inflight_requests",
+			Help: "Requests currently being served by this instance",
+		},
+		func() float64 { return float64(inFlight.Load()) },
+	)
+
+	utilizationRatio = prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "app1_autoscaling_utilization_ratio",
+			Help: "Average in-flight requests over the last decision window divided by AUTOSCALE_CAPACITY",
+		},
+	)
+
+	routeP95 = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "app1_autoscaling_route_p95_seconds",
+			Help: "p95 latency per route over the last decision window",
+		},
+		[]string{"route"},
+	)
+
+	desiredReplicas = prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "app1_autoscaling_desired_replicas",
+			Help: "Replica count a utilization-based HPA would choose right now",
+		},
+	)
+)
+
+var inFlight atomic.Int64
+
+func init() {
+	prometheus.MustRegister(inFlightRequests)
+	prometheus.MustRegister(utilizationRatio)
+	prometheus.MustRegister(routeP95)
+	prometheus.MustRegister(desiredReplicas)
+	describeMetric("app1_autoscaling_utilization_ratio", metricMeta{Panel: "gauge", Unit: "percentunit", Thresholds: []float64{0.7, 0.9}})
+	describeMetric("app1_autoscaling_desired_replicas", metricMeta{Panel: "stat", Unit: "none"})
+}
+
+// Máximo de latencias guardadas por ruta dentro de una ventana
+const maxRouteSamples = 2000
+
+type autoscaler struct {
+	capacity    float64
+	target      float64
+	minReplicas int
+	maxReplicas int
+	window      time.Duration
+
+	mu        sync.Mutex
+	inFlight  []int64
+	latencies map[string][]time.Duration
+}
+
+var scaling = newAutoscaler()
+
+func newAutoscaler() *autoscaler {
+	a := &autoscaler{
+		capacity:    10,
+		target:      0.7,
+		minReplicas: 1,
+		maxReplicas: 10,
+		window:      30 * time.Second,
+		latencies:   make(map[string][]time.Duration),
+	}
+
+	if v, err := strconv.ParseFloat(os.Getenv("AUTOSCALE_CAPACITY"), 64); err == nil && v > 0 {
+		a.capacity = v
+	}
+	if v, err := strconv.ParseFloat(os.Getenv("AUTOSCALE_TARGET_UTILIZATION"), 64); err == nil && v > 0 && v <= 1 {
+		a.target = v
+	}
+	if v, err := strconv.Atoi(os.Getenv("AUTOSCALE_MIN_REPLICAS")); err == nil && v > 0 {
+		a.minReplicas = v
+	}
+	if v, err := strconv.Atoi(os.Getenv("AUTOSCALE_MAX_REPLICAS")); err == nil && v >= a.minReplicas {
+		a.maxReplicas = v
+	}
+
+	return a
+}
+
+// autoscalingMiddleware cuenta las requests en curso y guarda la latencia
+// por patrón de ruta del mux, para no abrir cardinalidad con paths libres.
+func autoscalingMiddleware(mux *http.ServeMux) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		route := routeTemplate(mux, r)
+
+		inFlight.Add(1)
+		start := time.Now()
+		defer func() {
+			inFlight.Add(-1)
+			scaling.observe(route, time.Since(start))
+		}()
+
+		mux.ServeHTTP(w, r)
+	})
+}
+
+func (a *autoscaler) observe(route string, latency time.Duration) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	samples := a.latencies[route]
+	if len(samples) >= maxRouteSamples {
+		samples = samples[1:]
+	}
+	a.latencies[route] = append(samples, latency)
+}
+
+// run muestrea las requests en curso cada segundo y al cerrar cada ventana
+// calcula la decisión que tomaría un HPA con el algoritmo estándar:
+// deseadas = ceil(actuales * utilización / objetivo), con tolerancia del 10%.
+func (a *autoscaler) run(ctx context.Context) {
+	sampler := time.NewTicker(time.Second)
+	defer sampler.Stop()
+	decision := time.NewTicker(a.window)
+	defer decision.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-sampler.C:
+			a.mu.Lock()
+			a.inFlight = append(a.inFlight, inFlight.Load())
+			a.mu.Unlock()
+		case <-decision.C:
+			a.decide()
+		}
+	}
+}
+
+func (a *autoscaler) decide() {
+	a.mu.Lock()
+	samples, latencies := a.inFlight, a.latencies
+	a.inFlight, a.latencies = nil, make(map[string][]time.Duration)
+	a.mu.Unlock()
+
+	sum := 0.0
+	for _, s := range samples {
+		sum += float64(s)
+	}
+	utilization := 0.0
+	if len(samples) > 0 {
+		utilization = sum / float64(len(samples)) / a.capacity
+	}
+	utilizationRatio.Set(utilization)
+
+	for route, values := range latencies {
+		sort.Slice(values, func(i, j int) bool { return values[i] < values[j] })
+		idx := int(math.Ceil(float64(len(values))*0.95)) - 1
+		routeP95.WithLabelValues(route).Set(values[idx].Seconds())
+	}
+
+	current := a.currentReplicas()
+	desired := current
+	if ratio := utilization / a.target; math.Abs(ratio-1) > 0.1 {
+		desired = int(math.Ceil(float64(current) * ratio))
+	}
+	if desired < a.minReplicas {
+		desired = a.minReplicas
+	}
+	if desired > a.maxReplicas {
+		desired = a.maxReplicas
+	}
+	desiredReplicas.Set(float64(desired))
+
+	action := "none"
+	switch {
+	case desired > current:
+		action = "scale_up"
+	case desired < current:
+		action = "scale_down"
+	}
+
+	logWithFields("info", fmt.Sprintf("Simulated HPA decision: %s from %d to %d replicas", action, current, desired), "", map[string]interface{}{
+		"hpa_action":             action,
+		"hpa_current_replicas":   current,
+		"hpa_desired_replicas":   desired,
+		"hpa_utilization":        math.Round(utilization*1000) / 1000,
+		"hpa_target_utilization": a.target,
+	})
+}
+
+// currentReplicas usa el descubrimiento de /peers; sin él asume una réplica.
+func (a *autoscaler) currentReplicas() int {
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
+	_, addrs, err := discoverPeers(ctx)
+	if err != nil || len(addrs) == 0 {
+		return 1
+	}
+	return len(addrs)
+}
@@ -0,0 +1,227 @@
+package main
+
+import (
+	"net"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"go.opentelemetry.io/otel/attribute"
+	oteltrace "go.opentelemetry.io/otel/trace"
+)
+
+var (
+	botRequests = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "app1_bot_requests_total",
+			Help: "Requests classified by the bot detector (bot or human) and the action taken",
+		},
+		[]string{"classification", "action"},
+	)
+
+	botScore = prometheus.NewHistogram(
+		prometheus.HistogramOpts{
+			Name:    "app1_bot_score",
+			Help:    "Bot likelihood score (0-1) assigned to each request",
+			Buckets: prometheus.LinearBuckets(0.1, 0.1, 10),
+		},
+	)
+)
+
+func init() {
+	prometheus.MustRegister(botRequests)
+	prometheus.MustRegister(botScore)
+}
+
+// User-Agents de herramientas, librerías HTTP y crawlers
+var botUserAgent = regexp.MustCompile(`(?i)(bot|crawler|spider|scrapy|curl|wget|python-requests|go-http-client|sqlmap|nikto|httpclient|java/)`)
+
+// Rutas que consultan probes y Prometheus, que no tienen sentido puntuar
+var botExemptPaths = map[string]bool{"/metrics": true, "/health": true}
+
+// Ventana del contador de requests por IP
+const botRateWindow = 10 * time.Second
+
+// botSettings se reemplaza entero al recargar la configuración.
+type botSettings struct {
+	threshold     float64
+	rateThreshold int
+	action        string
+	tarpitDelay   time.Duration
+}
+
+type botDetector struct {
+	settings atomic.Pointer[botSettings]
+
+	mu      sync.Mutex
+	windows map[string]*rateWindow
+}
+
+type rateWindow struct {
+	start time.Time
+	count int
+}
+
+var bots = newBotDetector()
+
+func newBotDetector() *botDetector {
+	d := &botDetector{windows: make(map[string]*rateWindow)}
+	d.settings.Store(loadBotSettings())
+	return d
+}
+
+func loadBotSettings() *botSettings {
+	s := &botSettings{
+		threshold:     0.5,
+		rateThreshold: 20,
+		action:        "tag",
+		tarpitDelay:   2 * time.Second,
+	}
+
+	if v, err := strconv.ParseFloat(getConfig("BOT_SCORE_THRESHOLD"), 64); err == nil && v > 0 && v <= 1 {
+		s.threshold = v
+	}
+	if v, err := strconv.Atoi(getConfig("BOT_RATE_THRESHOLD")); err == nil && v > 0 {
+		s.rateThreshold = v
+	}
+	switch action := getConfig("BOT_ACTION"); action {
+	case "tag", "block", "tarpit":
+		s.action = action
+	}
+	if v, err := time.ParseDuration(getConfig("BOT_TARPIT_DELAY")); err == nil && v > 0 {
+		s.tarpitDelay = v
+	}
+
+	return s
+}
+
+// clientIP usa el primer X-Forwarded-For si existe y si no la dirección
+// remota. Es falsificable, pero en el lab el tráfico llega por el Service.
+func clientIP(r *http.Request) string {
+	if forwarded := r.Header.Get("X-Forwarded-For"); forwarded != "" {
+		first, _, _ := strings.Cut(forwarded, ",")
+		return strings.TrimSpace(first)
+	}
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+// rate cuenta la request en la ventana de la IP y devuelve el total.
+func (d *botDetector) rate(ip string) int {
+	now := time.Now()
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	// Cota de memoria: con demasiadas IPs distintas se empieza de cero
+	if len(d.windows) > 10000 {
+		d.windows = make(map[string]*rateWindow)
+	}
+
+	w, ok := d.windows[ip]
+	if !ok || now.Sub(w.start) > botRateWindow {
+		w = &rateWindow{start: now}
+		d.windows[ip] = w
+	}
+	w.count++
+	return w.count
+}
+
+// score suma señales independientes y devuelve el score (máximo 1) junto
+// con las razones que lo explican.
+func (d *botDetector) score(r *http.Request) (float64, []string) {
+	score := 0.0
+	var reasons []string
+
+	ua := r.UserAgent()
+	switch {
+	case ua == "":
+		score += 0.4
+		reasons = append(reasons, "missing_user_agent")
+	case botUserAgent.MatchString(ua):
+		score += 0.5
+		reasons = append(reasons, "automation_user_agent")
+	}
+
+	if r.Header.Get("Accept") == "" {
+		score += 0.1
+		reasons = append(reasons, "missing_accept")
+	}
+	if r.Header.Get("Accept-Language") == "" {
+		score += 0.2
+		reasons = append(reasons, "missing_accept_language")
+	}
+
+	if d.rate(clientIP(r)) > d.settings.Load().rateThreshold {
+		score += 0.3
+		reasons = append(reasons, "high_request_rate")
+	}
+
+	if score > 1 {
+		score = 1
+	}
+	return score, reasons
+}
+
+// botMiddleware puntúa cada request, la marca en el span y, según
+// BOT_ACTION, deja pasar, bloquea con 403 o demora a los bots sospechosos.
+func botMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if botExemptPaths[r.URL.Path] {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		settings := bots.settings.Load()
+		score, reasons := bots.score(r)
+		suspected := score >= settings.threshold
+		botScore.Observe(score)
+
+		span := oteltrace.SpanFromContext(r.Context())
+		span.SetAttributes(
+			attribute.Float64("bot.score", score),
+			attribute.Bool("bot.suspected", suspected),
+		)
+		if len(reasons) > 0 {
+			span.SetAttributes(attribute.StringSlice("bot.reasons", reasons))
+		}
+
+		if !suspected {
+			botRequests.WithLabelValues("human", "allow").Inc()
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		action := settings.action
+		botRequests.WithLabelValues("bot", action).Inc()
+		logWithFields("warn", "Suspected bot request to "+r.URL.Path, span.SpanContext().TraceID().String(), map[string]interface{}{
+			"bot_score":   score,
+			"bot_reasons": reasons,
+			"bot_action":  action,
+			"client_ip":   clientIP(r),
+			"user_agent":  r.UserAgent(),
+		})
+
+		switch action {
+		case "block":
+			http.Error(w, "forbidden", http.StatusForbidden)
+			return
+		case "tarpit":
+			select {
+			case <-time.After(settings.tarpitDelay):
+			case <-r.Context().Done():
+				return
+			}
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
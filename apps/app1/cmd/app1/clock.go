@@ -0,0 +1,90 @@
+package main
+
+import (
+	"math"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var (
+	timeCompressionFactor = prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "app1_time_compression_factor",
+			Help: "Simulated seconds per real second used by background jobs",
+		},
+	)
+
+	simulatedTime = prometheus.NewGaugeFunc(
+		prometheus.GaugeOpts{
+			Name: "app1_simulated_time_seconds",
+			Help: "Current virtual clock time as a Unix timestamp",
+		},
+		func() float64 { return float64(labClock.Now().Unix()) },
+	)
+)
+
+// Reloj virtual compartido por los jobs en background
+var labClock = newVirtualClock()
+
+func init() {
+	prometheus.MustRegister(timeCompressionFactor)
+	prometheus.MustRegister(simulatedTime)
+	timeCompressionFactor.Set(labClock.factor)
+}
+
+// virtualClock avanza factor segundos simulados por cada segundo real.
+// Con factor 1 equivale al reloj del sistema.
+type virtualClock struct {
+	factor       float64
+	realStart    time.Time
+	virtualStart time.Time
+}
+
+func newVirtualClock() *virtualClock {
+	factor := 1.0
+	if v := os.Getenv("TIME_COMPRESSION"); v != "" {
+		if parsed, err := strconv.ParseFloat(v, 64); err == nil && parsed >= 1 {
+			factor = parsed
+		}
+	}
+
+	now := time.Now()
+	clock := &virtualClock{factor: factor, realStart: now, virtualStart: now}
+
+	// En modo comprimido la línea de tiempo siempre arranca a medianoche,
+	// para que cada demo recorra el mismo día simulado
+	if clock.compressed() {
+		clock.virtualStart = time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	}
+
+	return clock
+}
+
+func (c *virtualClock) compressed() bool {
+	return c.factor > 1
+}
+
+func (c *virtualClock) Now() time.Time {
+	elapsed := time.Since(c.realStart)
+	return c.virtualStart.Add(time.Duration(float64(elapsed) * c.factor))
+}
+
+// Interval convierte una duración simulada a tiempo real, con un mínimo
+// de un segundo para no saturar los scrapes de Prometheus.
+func (c *virtualClock) Interval(simulated time.Duration) time.Duration {
+	interval := time.Duration(float64(simulated) / c.factor)
+	if interval < time.Second {
+		interval = time.Second
+	}
+	return interval
+}
+
+// dailyLoad devuelve la carga relativa (0..1) para la hora simulada,
+// con el valle a las 02:00 y el pico a las 14:00.
+func dailyLoad(t time.Time) float64 {
+	hour := float64(t.Hour()) + float64(t.Minute())/60
+	return 0.5 - 0.5*math.Cos(2*math.Pi*(hour-2)/24)
+}
@@ -0,0 +1,202 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// configKey describe una variable de configuración. value devuelve el valor
+// ya resuelto por el componente que la usa (defaults y validación incluidos),
+// así el dump muestra lo que el proceso aplica y no solo lo que se pidió.
+type configKey struct {
+	name  string
+	value func() interface{}
+	// secret: se muestra solo una huella corta del valor
+	secret bool
+	// perInstance: distinta en cada réplica, queda fuera del hash
+	perInstance bool
+}
+
+var configKeys = []configKey{
+	{name: "LOG_LEVEL", value: func() interface{} { return logLevelNames[logLevel.Load()] }},
+	{name: "PORT", value: func() interface{} { return envDefault("PORT", "8080") }},
+	{name: "BIND_ADDRESS", value: func() interface{} { return os.Getenv("BIND_ADDRESS") }},
+	{name: "LISTEN_NETWORK", value: func() interface{} { return envDefault("LISTEN_NETWORK", "tcp") }},
+	{name: "UNIX_SOCKET", value: func() interface{} { return os.Getenv("UNIX_SOCKET") }},
+	{name: "SERVICE_VERSION", value: func() interface{} { return serviceVersion }},
+	{name: "POD_IP", value: func() interface{} { return os.Getenv("POD_IP") }, perInstance: true},
+	{name: "PEERS", value: func() interface{} { return os.Getenv("PEERS") }},
+	{name: "PEER_DNS", value: func() interface{} { return os.Getenv("PEER_DNS") }},
+	{name: "TEMPO_ENDPOINT", value: func() interface{} { return maskURL(envDefault("TEMPO_ENDPOINT", "http://tempo:4318")) }},
+	{name: "TRACE_SAMPLING_RATIO", value: func() interface{} { return traceSampler.Ratio() }},
+	{name: "TRACE_SUPPRESS_ROUTES", value: func() interface{} { return sortedKeys(traceSampler.suppressedRoutes) }},
+	{name: "TRACE_SUPPRESS_RATIO", value: func() interface{} { return envDefault("TRACE_SUPPRESS_RATIO", "0") }},
+	{name: "TELEMETRY_REDACT", value: func() interface{} { return telemetryRedactor.rules }},
+	// El salt aleatorio es distinto en cada proceso: se muestra vacío para no marcar drift
+	{name: "TELEMETRY_REDACT_SALT", value: func() interface{} { return telemetryRedactor.configuredSalt() }, secret: true},
+	{name: "SPAN_BUFFER_DIR", value: func() interface{} { return os.Getenv("SPAN_BUFFER_DIR") }},
+	{name: "SPAN_BUFFER_MAX_MB", value: func() interface{} { return envDefault("SPAN_BUFFER_MAX_MB", "16") }},
+	{name: "TIME_COMPRESSION", value: func() interface{} { return labClock.factor }},
+	{name: "SIMULATOR_CPU_THRESHOLD", value: func() interface{} { return envDefault("SIMULATOR_CPU_THRESHOLD", "80") }},
+	{name: "SIMULATOR_RSS_THRESHOLD_MB", value: func() interface{} { return envDefault("SIMULATOR_RSS_THRESHOLD_MB", "100") }},
+	{name: "COLD_START_REQUESTS", value: func() interface{} { return startup.coldRequests }},
+	{name: "SHUTDOWN_TIMEOUT", value: func() interface{} { return shutdownTimeout().String() }},
+	{name: "PROMETHEUS_URL", value: func() interface{} { return maskURL(scorer.prometheusURL) }},
+	{name: "HEALTH_MAX_ERROR_RATE", value: func() interface{} { return scorer.maxErrorRate }},
+	{name: "HEALTH_P95_TARGET", value: func() interface{} { return scorer.p95Target.String() }},
+	{name: "AUTOSCALE_CAPACITY", value: func() interface{} { return scaling.capacity }},
+	{name: "AUTOSCALE_TARGET_UTILIZATION", value: func() interface{} { return scaling.target }},
+	{name: "AUTOSCALE_MIN_REPLICAS", value: func() interface{} { return scaling.minReplicas }},
+	{name: "AUTOSCALE_MAX_REPLICAS", value: func() interface{} { return scaling.maxReplicas }},
+	{name: "CLIENT_DAILY_QUOTA", value: func() interface{} { return meter.settings.Load().defaultQuota }},
+	{name: "CLIENT_QUOTAS", value: func() interface{} { return maskedQuotas() }},
+	{name: "CLIENT_OVERFLOW_QUOTA", value: func() interface{} { return meter.settings.Load().overflowQuota }},
+	{name: "BOT_SCORE_THRESHOLD", value: func() interface{} { return bots.settings.Load().threshold }},
+	{name: "BOT_RATE_THRESHOLD", value: func() interface{} { return bots.settings.Load().rateThreshold }},
+	{name: "BOT_ACTION", value: func() interface{} { return bots.settings.Load().action }},
+	{name: "BOT_TARPIT_DELAY", value: func() interface{} { return bots.settings.Load().tarpitDelay.String() }},
+	{name: "GEOIP_DATABASE", value: func() interface{} { return os.Getenv("GEOIP_DATABASE") }},
+	{name: "GEOIP_CIDR_FILE", value: func() interface{} { return os.Getenv("GEOIP_CIDR_FILE") }},
+	{name: "GEOIP_MAX_COUNTRIES", value: func() interface{} { return countries.limit }},
+	{name: "ACCESS_LOG_SINK", value: func() interface{} { return envDefault("ACCESS_LOG_SINK", "stderr") }},
+	{name: "ACCESS_LOG_SAMPLE", value: func() interface{} { return accessLog.sampleRatio() }},
+	{name: "DEPENDENCIES", value: func() interface{} { return declaredDependencies }},
+	{name: "DEPENDENCY_ERROR_RATES", value: func() interface{} { return dependencyErrorRates.snapshot() }},
+	{name: "SLI_LATENCY_THRESHOLDS", value: func() interface{} { return durationStrings(sliLatencyThresholds) }},
+}
+
+type configValue struct {
+	Value  interface{} `json:"value"`
+	Source string      `json:"source"`
+}
+
+type configDump struct {
+	Service    string                 `json:"service"`
+	InstanceID string                 `json:"instance_id"`
+	Hash       string                 `json:"hash"`
+	Config     map[string]configValue `json:"config"`
+}
+
+// configHashDesc se expone como info metric: con todas las réplicas iguales
+// count(count by (hash) (app1_config_info)) vale 1.
+var configHashDesc = prometheus.NewDesc(
+	"app1_config_info",
+	"Hash of the resolved configuration (secrets fingerprinted, per-instance keys excluded); replicas with different hashes have drifted",
+	[]string{"hash"}, nil,
+)
+
+// configCollector calcula el hash en cada scrape, así refleja también los
+// cambios en caliente (por ejemplo el ratio de muestreo).
+type configCollector struct{}
+
+func (configCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- configHashDesc
+}
+
+func (configCollector) Collect(ch chan<- prometheus.Metric) {
+	ch <- prometheus.MustNewConstMetric(configHashDesc, prometheus.GaugeValue, 1, resolveConfig().Hash)
+}
+
+func init() {
+	prometheus.MustRegister(configCollector{})
+}
+
+// resolveConfig arma el dump con los secretos ya enmascarados. El hash se
+// calcula sobre ese mismo dump, sin las claves propias de cada instancia.
+func resolveConfig() configDump {
+	dump := configDump{Service: "app1", InstanceID: instanceID, Config: make(map[string]configValue)}
+	hashed := make(map[string]interface{})
+
+	for _, k := range configKeys {
+		value := k.value()
+		if k.secret {
+			value = maskSecret(value.(string))
+		}
+
+		dump.Config[k.name] = configValue{Value: value, Source: configSource(k.name)}
+
+		if !k.perInstance {
+			hashed[k.name] = value
+		}
+	}
+
+	// json.Marshal ordena las claves de los mapas, el resultado es estable
+	raw, _ := json.Marshal(hashed)
+	sum := sha256.Sum256(raw)
+	dump.Hash = hex.EncodeToString(sum[:6])
+	return dump
+}
+
+// maskSecret deja una huella corta, suficiente para ver si dos réplicas
+// tienen el mismo secreto sin exponerlo.
+func maskSecret(value string) string {
+	if value == "" {
+		return ""
+	}
+	sum := sha256.Sum256([]byte(value))
+	return "****" + hex.EncodeToString(sum[:4])
+}
+
+// maskURL oculta la contraseña de las URLs con credenciales.
+func maskURL(raw string) string {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return raw
+	}
+	return u.Redacted()
+}
+
+// maskedQuotas identifica las keys por el mismo hash corto que /usage.
+func maskedQuotas() map[string]int64 {
+	settings := meter.settings.Load()
+	quotas := make(map[string]int64, len(settings.quotas))
+	for key, quota := range settings.quotas {
+		quotas[clientID(key)] = quota
+	}
+	return quotas
+}
+
+func sortedKeys(m map[string]bool) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func durationStrings(m map[string]time.Duration) map[string]string {
+	out := make(map[string]string, len(m))
+	for k, d := range m {
+		out[k] = d.String()
+	}
+	return out
+}
+
+func envDefault(key, fallback string) string {
+	if v := os.Getenv(key); v != "" {
+		return v
+	}
+	return fallback
+}
+
+func configHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.Header().Set("Allow", "GET")
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	enc.Encode(resolveConfig())
+}
@@ -0,0 +1,209 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/procfs"
+)
+
+// Las métricas de proceso (process_open_fds, process_resident_memory_bytes,
+// process_cpu_seconds_total) ya las exporta el registry por defecto. Acá se
+// suman los hilos del sistema y los límites del contenedor, para ver la
+// saturación contra el límite del pod sin depender de node-exporter o cAdvisor.
+var (
+	processThreadsDesc = prometheus.NewDesc(
+		"app1_process_threads",
+		"OS threads of the app1 process",
+		nil, nil,
+	)
+	containerMemoryLimitDesc = prometheus.NewDesc(
+		"app1_container_memory_limit_bytes",
+		"Memory limit of the container cgroup (absent when unlimited)",
+		nil, nil,
+	)
+	containerMemoryUsageDesc = prometheus.NewDesc(
+		"app1_container_memory_usage_bytes",
+		"Memory charged to the container cgroup, page cache included",
+		nil, nil,
+	)
+	containerCPULimitDesc = prometheus.NewDesc(
+		"app1_container_cpu_limit_cores",
+		"CPU quota of the container cgroup in cores (absent when unlimited)",
+		nil, nil,
+	)
+	containerCPUPeriodsDesc = prometheus.NewDesc(
+		"app1_container_cpu_periods_total",
+		"CFS enforcement periods elapsed for the container cgroup",
+		nil, nil,
+	)
+	containerCPUThrottledPeriodsDesc = prometheus.NewDesc(
+		"app1_container_cpu_throttled_periods_total",
+		"CFS periods in which the container cgroup was throttled",
+		nil, nil,
+	)
+	containerCPUThrottledSecondsDesc = prometheus.NewDesc(
+		"app1_container_cpu_throttled_seconds_total",
+		"Total time the container cgroup was throttled",
+		nil, nil,
+	)
+)
+
+const cgroupRoot = "/sys/fs/cgroup"
+
+// Con cgroup v1 "sin límite" es un número enorme alineado a página
+const cgroupUnlimited = 1 << 62
+
+// containerCollector lee el cgroup en cada scrape. Con el cgroup namespace
+// del contenedor el propio cgroup queda montado en la raíz de cgroupRoot.
+// Fuera de Linux o sin cgroup no emite nada.
+type containerCollector struct{}
+
+func (containerCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- processThreadsDesc
+	ch <- containerMemoryLimitDesc
+	ch <- containerMemoryUsageDesc
+	ch <- containerCPULimitDesc
+	ch <- containerCPUPeriodsDesc
+	ch <- containerCPUThrottledPeriodsDesc
+	ch <- containerCPUThrottledSecondsDesc
+}
+
+func (containerCollector) Collect(ch chan<- prometheus.Metric) {
+	if proc, err := procfs.Self(); err == nil {
+		if stat, err := proc.Stat(); err == nil {
+			ch <- prometheus.MustNewConstMetric(processThreadsDesc, prometheus.GaugeValue, float64(stat.NumThreads))
+		}
+	}
+
+	stats := readCgroupStats(cgroupRoot)
+	emit := func(desc *prometheus.Desc, kind prometheus.ValueType, v float64, ok bool) {
+		if ok {
+			ch <- prometheus.MustNewConstMetric(desc, kind, v)
+		}
+	}
+	emit(containerMemoryLimitDesc, prometheus.GaugeValue, stats.memoryLimit, stats.hasMemoryLimit)
+	emit(containerMemoryUsageDesc, prometheus.GaugeValue, stats.memoryUsage, stats.hasMemoryUsage)
+	emit(containerCPULimitDesc, prometheus.GaugeValue, stats.cpuLimit, stats.hasCPULimit)
+	emit(containerCPUPeriodsDesc, prometheus.CounterValue, stats.periods, stats.hasCPUStat)
+	emit(containerCPUThrottledPeriodsDesc, prometheus.CounterValue, stats.throttledPeriods, stats.hasCPUStat)
+	emit(containerCPUThrottledSecondsDesc, prometheus.CounterValue, stats.throttledSeconds, stats.hasCPUStat)
+}
+
+func init() {
+	prometheus.MustRegister(containerCollector{})
+}
+
+type cgroupStats struct {
+	memoryLimit, memoryUsage                    float64
+	cpuLimit                                    float64
+	periods, throttledPeriods, throttledSeconds float64
+
+	hasMemoryLimit, hasMemoryUsage, hasCPULimit, hasCPUStat bool
+}
+
+// readCgroupStats usa la interfaz unificada de cgroup v2 si está montada y
+// si no los controladores memory y cpu de cgroup v1.
+func readCgroupStats(root string) cgroupStats {
+	if _, err := os.Stat(filepath.Join(root, "cgroup.controllers")); err == nil {
+		return readCgroupV2(root)
+	}
+	return readCgroupV1(root)
+}
+
+func readCgroupV2(root string) cgroupStats {
+	var s cgroupStats
+
+	if v, ok := readCgroupValue(filepath.Join(root, "memory.max")); ok {
+		s.memoryLimit, s.hasMemoryLimit = v, v < cgroupUnlimited
+	}
+	s.memoryUsage, s.hasMemoryUsage = readCgroupValue(filepath.Join(root, "memory.current"))
+
+	// cpu.max: "<quota> <period>" o "max <period>"
+	if raw, err := os.ReadFile(filepath.Join(root, "cpu.max")); err == nil {
+		fields := strings.Fields(string(raw))
+		if len(fields) == 2 {
+			quota, errQuota := strconv.ParseFloat(fields[0], 64)
+			period, errPeriod := strconv.ParseFloat(fields[1], 64)
+			if errQuota == nil && errPeriod == nil && period > 0 {
+				s.cpuLimit, s.hasCPULimit = quota/period, true
+			}
+		}
+	}
+
+	if stat, ok := readCgroupKeyValues(filepath.Join(root, "cpu.stat")); ok {
+		if _, enforced := stat["nr_periods"]; enforced {
+			s.periods = stat["nr_periods"]
+			s.throttledPeriods = stat["nr_throttled"]
+			s.throttledSeconds = stat["throttled_usec"] / 1e6
+			s.hasCPUStat = true
+		}
+	}
+	return s
+}
+
+func readCgroupV1(root string) cgroupStats {
+	var s cgroupStats
+
+	memory := filepath.Join(root, "memory")
+	if v, ok := readCgroupValue(filepath.Join(memory, "memory.limit_in_bytes")); ok {
+		s.memoryLimit, s.hasMemoryLimit = v, v < cgroupUnlimited
+	}
+	s.memoryUsage, s.hasMemoryUsage = readCgroupValue(filepath.Join(memory, "memory.usage_in_bytes"))
+
+	// Algunas distros montan cpu y cpuacct juntos, sin el symlink cpu
+	cpu := filepath.Join(root, "cpu")
+	if _, err := os.Stat(cpu); err != nil {
+		cpu = filepath.Join(root, "cpu,cpuacct")
+	}
+
+	quota, okQuota := readCgroupValue(filepath.Join(cpu, "cpu.cfs_quota_us"))
+	period, okPeriod := readCgroupValue(filepath.Join(cpu, "cpu.cfs_period_us"))
+	if okQuota && okPeriod && quota > 0 && period > 0 {
+		s.cpuLimit, s.hasCPULimit = quota/period, true
+	}
+
+	if stat, ok := readCgroupKeyValues(filepath.Join(cpu, "cpu.stat")); ok {
+		s.periods = stat["nr_periods"]
+		s.throttledPeriods = stat["nr_throttled"]
+		s.throttledSeconds = stat["throttled_time"] / 1e9
+		s.hasCPUStat = true
+	}
+	return s
+}
+
+// readCgroupValue lee un archivo de un solo valor; "max" cuenta como sin límite.
+func readCgroupValue(path string) (float64, bool) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return 0, false
+	}
+	value := strings.TrimSpace(string(raw))
+	if value == "max" {
+		return cgroupUnlimited, true
+	}
+	v, err := strconv.ParseFloat(value, 64)
+	return v, err == nil
+}
+
+// readCgroupKeyValues lee archivos de líneas "clave valor" como cpu.stat.
+func readCgroupKeyValues(path string) (map[string]float64, bool) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, false
+	}
+	values := make(map[string]float64)
+	for _, line := range strings.Split(string(raw), "\n") {
+		key, value, ok := strings.Cut(strings.TrimSpace(line), " ")
+		if !ok {
+			continue
+		}
+		if v, err := strconv.ParseFloat(value, 64); err == nil {
+			values[key] = v
+		}
+	}
+	return values, true
+}
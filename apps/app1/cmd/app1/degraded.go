@@ -0,0 +1,127 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+)
+
+var responseCompleteness = prometheus.NewCounterVec(
+	prometheus.CounterOpts{
+		Name: "app1_response_completeness_total",
+		Help: "Successful responses by endpoint and completeness (full, or partial when an optional dependency failed)",
+	},
+	[]string{"endpoint", "completeness"},
+)
+
+func init() {
+	prometheus.MustRegister(responseCompleteness)
+}
+
+var errDependencyUnavailable = errors.New("dependency unavailable")
+
+// errorRates guarda las tasas de falla simuladas por dependencia
+// (DEPENDENCY_ERROR_RATES="nombre=tasa,..."), recargables en caliente.
+type errorRates struct {
+	rates atomic.Pointer[map[string]float64]
+}
+
+var dependencyErrorRates = newErrorRates()
+
+func newErrorRates() *errorRates {
+	r := &errorRates{}
+	r.reload()
+	return r
+}
+
+func (r *errorRates) reload() {
+	rates := loadDependencyErrorRates()
+	r.rates.Store(&rates)
+}
+
+func (r *errorRates) rate(dependency string) float64 {
+	return (*r.rates.Load())[dependency]
+}
+
+func (r *errorRates) snapshot() map[string]float64 {
+	return *r.rates.Load()
+}
+
+func loadDependencyErrorRates() map[string]float64 {
+	rates := map[string]float64{"recommendations": 0.05}
+
+	value := getConfig("DEPENDENCY_ERROR_RATES")
+	if value == "" {
+		return rates
+	}
+	for _, entry := range strings.Split(value, ",") {
+		name, rate, _ := strings.Cut(strings.TrimSpace(entry), "=")
+		v, err := strconv.ParseFloat(rate, 64)
+		if err != nil || v < 0 || v > 1 {
+			logMessage("warn", "Invalid DEPENDENCY_ERROR_RATES entry "+entry+", ignoring", "")
+			continue
+		}
+		rates[name] = v
+	}
+	return rates
+}
+
+// optionalDependency indica si la dependencia se declaró optional en
+// DEPENDENCIES. Las que no están declaradas se tratan como críticas.
+func optionalDependency(name string) bool {
+	for _, d := range declaredDependencies {
+		if d.Name == name {
+			return d.Criticality == "optional"
+		}
+	}
+	return false
+}
+
+// dependencyCall es una llamada simulada: un span con latencia aleatoria
+// de hasta maxLatency que falla según DEPENDENCY_ERROR_RATES.
+type dependencyCall struct {
+	span       string
+	dependency string
+	maxLatency time.Duration
+}
+
+func (c dependencyCall) do(ctx context.Context) error {
+	_, span := otel.Tracer("app1").Start(ctx, c.span)
+	defer span.End()
+	span.SetAttributes(attribute.String("peer.service", c.dependency))
+
+	start := time.Now()
+	time.Sleep(time.Duration(rand.Int63n(int64(c.maxLatency))))
+
+	var err error
+	if rand.Float64() < dependencyErrorRates.rate(c.dependency) {
+		err = errDependencyUnavailable
+		span.SetStatus(codes.Error, err.Error())
+	}
+	observeDependency(c.dependency, start, err)
+	return err
+}
+
+// callDependencies ejecuta las llamadas en orden. Si falla una dependencia
+// opcional se sigue sin ella y se devuelve en degraded; si falla una
+// crítica se corta y se devuelve el error.
+func callDependencies(ctx context.Context, calls ...dependencyCall) (degraded []string, failed string, err error) {
+	for _, call := range calls {
+		if err := call.do(ctx); err != nil {
+			if !optionalDependency(call.dependency) {
+				return degraded, call.dependency, err
+			}
+			degraded = append(degraded, call.dependency)
+		}
+	}
+	return degraded, "", nil
+}
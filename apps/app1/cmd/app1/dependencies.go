@@ -0,0 +1,33 @@
+package main
+
+import (
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Buckets exponenciales de 1ms a ~32s: el mismo layout para todas las
+// dependencias permite comparar filas en los paneles heatmap de Grafana.
+var dependencyBuckets = prometheus.ExponentialBuckets(0.001, 2, 16)
+
+var dependencyDuration = prometheus.NewHistogramVec(
+	prometheus.HistogramOpts{
+		Name:    "app1_dependency_duration_seconds",
+		Help:    "Duration of calls from app1 to its dependencies",
+		Buckets: dependencyBuckets,
+	},
+	[]string{"dependency", "result"},
+)
+
+func init() {
+	prometheus.MustRegister(dependencyDuration)
+}
+
+// observeDependency registra la duración de una llamada a una dependencia.
+func observeDependency(dependency string, start time.Time, err error) {
+	result := "success"
+	if err != nil {
+		result = "error"
+	}
+	dependencyDuration.WithLabelValues(dependency, result).Observe(time.Since(start).Seconds())
+}
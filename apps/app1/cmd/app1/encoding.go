@@ -0,0 +1,169 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/vmihailenco/msgpack/v5"
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+const (
+	formatJSON     = "json"
+	formatMsgpack  = "msgpack"
+	formatProtobuf = "protobuf"
+)
+
+var (
+	serializationDuration = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name:    "app1_response_serialization_duration_seconds",
+			Help:    "Time spent encoding HTTP responses, by format",
+			Buckets: prometheus.ExponentialBuckets(0.000001, 2, 16),
+		},
+		[]string{"endpoint", "format"},
+	)
+
+	responseSizeBytes = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name:    "app1_response_size_bytes",
+			Help:    "Size of encoded HTTP responses, by format",
+			Buckets: prometheus.ExponentialBuckets(16, 2, 10),
+		},
+		[]string{"endpoint", "format"},
+	)
+
+	// Content-Type devuelto para cada formato soportado
+	formatContentTypes = map[string]string{
+		formatJSON:     "application/json",
+		formatMsgpack:  "application/msgpack",
+		formatProtobuf: "application/x-protobuf",
+	}
+
+	// Media types aceptados en el header Accept para cada formato
+	mediaTypeFormats = map[string]string{
+		"application/json":       formatJSON,
+		"application/*":          formatJSON,
+		"*/*":                    formatJSON,
+		"application/msgpack":    formatMsgpack,
+		"application/x-msgpack":  formatMsgpack,
+		"application/protobuf":   formatProtobuf,
+		"application/x-protobuf": formatProtobuf,
+	}
+)
+
+func init() {
+	prometheus.MustRegister(serializationDuration)
+	prometheus.MustRegister(responseSizeBytes)
+}
+
+// negotiateFormat elige el formato de respuesta según el header Accept,
+// respetando los q-values. Si no hay coincidencias se usa JSON.
+func negotiateFormat(accept string) string {
+	best := formatJSON
+	bestQ := -1.0
+
+	for _, part := range strings.Split(accept, ",") {
+		mediaType, params, err := mime.ParseMediaType(strings.TrimSpace(part))
+		if err != nil {
+			continue
+		}
+
+		format, ok := mediaTypeFormats[mediaType]
+		if !ok {
+			continue
+		}
+
+		q := 1.0
+		if v, ok := params["q"]; ok {
+			if parsed, err := strconv.ParseFloat(v, 64); err == nil {
+				q = parsed
+			}
+		}
+
+		if q > 0 && q > bestQ {
+			best, bestQ = format, q
+		}
+	}
+
+	return best
+}
+
+func encodeResponse(format string, response Response) ([]byte, error) {
+	switch format {
+	case formatMsgpack:
+		var buf bytes.Buffer
+		enc := msgpack.NewEncoder(&buf)
+		enc.SetCustomStructTag("json")
+		if err := enc.Encode(response); err != nil {
+			return nil, err
+		}
+		return buf.Bytes(), nil
+	case formatProtobuf:
+		return encodeResponseProtobuf(response), nil
+	default:
+		return json.Marshal(response)
+	}
+}
+
+// encodeResponseProtobuf codifica Response siguiendo este esquema:
+//
+//	message Response {
+//	  string message = 1;
+//	  google.protobuf.Timestamp timestamp = 2;
+//	  string trace_id = 3;
+//	  bool partial = 4;
+//	  repeated string degraded_dependencies = 5;
+//	}
+func encodeResponseProtobuf(response Response) []byte {
+	var ts []byte
+	ts = protowire.AppendTag(ts, 1, protowire.VarintType)
+	ts = protowire.AppendVarint(ts, uint64(response.Timestamp.Unix()))
+	ts = protowire.AppendTag(ts, 2, protowire.VarintType)
+	ts = protowire.AppendVarint(ts, uint64(response.Timestamp.Nanosecond()))
+
+	var b []byte
+	b = protowire.AppendTag(b, 1, protowire.BytesType)
+	b = protowire.AppendString(b, response.Message)
+	b = protowire.AppendTag(b, 2, protowire.BytesType)
+	b = protowire.AppendBytes(b, ts)
+	b = protowire.AppendTag(b, 3, protowire.BytesType)
+	b = protowire.AppendString(b, response.TraceID)
+	if response.Partial {
+		b = protowire.AppendTag(b, 4, protowire.VarintType)
+		b = protowire.AppendVarint(b, 1)
+	}
+	for _, dep := range response.DegradedDependencies {
+		b = protowire.AppendTag(b, 5, protowire.BytesType)
+		b = protowire.AppendString(b, dep)
+	}
+	return b
+}
+
+// writeResponse codifica la respuesta en el formato negociado y registra
+// el tiempo de serialización para comparar formatos en los dashboards.
+func writeResponse(w http.ResponseWriter, r *http.Request, endpoint string, status int, response Response) {
+	format := negotiateFormat(r.Header.Get("Accept"))
+
+	start := time.Now()
+	body, err := encodeResponse(format, response)
+	serializationDuration.WithLabelValues(endpoint, format).Observe(time.Since(start).Seconds())
+
+	if err != nil {
+		errorRate.WithLabelValues("serialization").Inc()
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	responseSizeBytes.WithLabelValues(endpoint, format).Observe(float64(len(body)))
+
+	w.Header().Set("Content-Type", formatContentTypes[format])
+	w.Header().Set("Vary", "Accept")
+	w.WriteHeader(status)
+	w.Write(body)
+}
@@ -0,0 +1,236 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/oschwald/maxminddb-golang"
+	"github.com/prometheus/client_golang/prometheus"
+	"go.opentelemetry.io/otel/attribute"
+	oteltrace "go.opentelemetry.io/otel/trace"
+)
+
+var requestsByCountry = prometheus.NewCounterVec(
+	prometheus.CounterOpts{
+		Name: "app1_requests_by_country_total",
+		Help: "Requests by client country (ISO code) resolved via GeoIP; countries first seen after the GEOIP_MAX_COUNTRIES limit are grouped as other",
+	},
+	[]string{"country"},
+)
+
+func init() {
+	prometheus.MustRegister(requestsByCountry)
+}
+
+// Rangos simulados dentro de 100.64.0.0/10 (espacio compartido, no ruteable
+// en internet). El generador de tráfico usa la misma tabla para X-Forwarded-For.
+var defaultGeoCIDRs = map[string]string{
+	"100.64.0.0/16": "AR",
+	"100.65.0.0/16": "BR",
+	"100.66.0.0/16": "US",
+	"100.67.0.0/16": "ES",
+	"100.68.0.0/16": "DE",
+	"100.69.0.0/16": "IN",
+	"100.70.0.0/16": "JP",
+	"100.71.0.0/16": "AU",
+}
+
+// geoResolver traduce una IP a código de país ISO.
+type geoResolver interface {
+	Country(ip net.IP) string
+}
+
+type geoRange struct {
+	network *net.IPNet
+	country string
+}
+
+// staticGeoResolver resuelve con una tabla de CIDRs, el más específico primero.
+type staticGeoResolver struct {
+	ranges []geoRange
+}
+
+func newStaticGeoResolver(cidrs map[string]string) (*staticGeoResolver, error) {
+	r := &staticGeoResolver{}
+	for cidr, country := range cidrs {
+		_, network, err := net.ParseCIDR(cidr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid CIDR %q: %w", cidr, err)
+		}
+		r.ranges = append(r.ranges, geoRange{network: network, country: strings.ToUpper(country)})
+	}
+
+	sort.Slice(r.ranges, func(i, j int) bool {
+		a, _ := r.ranges[i].network.Mask.Size()
+		b, _ := r.ranges[j].network.Mask.Size()
+		return a > b
+	})
+	return r, nil
+}
+
+// loadGeoCIDRFile lee líneas "cidr país"; las vacías y # se ignoran.
+func loadGeoCIDRFile(path string) (map[string]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	cidrs := make(map[string]string)
+	scanner := bufio.NewScanner(f)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		fields := strings.Fields(text)
+		if len(fields) != 2 {
+			return nil, fmt.Errorf("line %d: expected \"<cidr> <country>\"", line)
+		}
+		cidrs[fields[0]] = fields[1]
+	}
+	return cidrs, scanner.Err()
+}
+
+func (r *staticGeoResolver) Country(ip net.IP) string {
+	for _, rng := range r.ranges {
+		if rng.network.Contains(ip) {
+			return rng.country
+		}
+	}
+	return ""
+}
+
+// maxmindGeoResolver usa una base GeoLite2/GeoIP2 Country o City.
+type maxmindGeoResolver struct {
+	reader *maxminddb.Reader
+}
+
+func (r *maxmindGeoResolver) Country(ip net.IP) string {
+	var record struct {
+		Country struct {
+			ISOCode string `maxminddb:"iso_code"`
+		} `maxminddb:"country"`
+	}
+	if err := r.reader.Lookup(ip, &record); err != nil {
+		return ""
+	}
+	return record.Country.ISOCode
+}
+
+// newGeoResolver elige MaxMind si GEOIP_DATABASE apunta a una base, si no
+// la tabla de GEOIP_CIDR_FILE o la tabla simulada por defecto.
+func newGeoResolver() geoResolver {
+	if path := os.Getenv("GEOIP_DATABASE"); path != "" {
+		reader, err := maxminddb.Open(path)
+		if err == nil {
+			return &maxmindGeoResolver{reader: reader}
+		}
+		logMessage("warn", "Error opening GeoIP database, using static CIDR map: "+err.Error(), "")
+	}
+
+	cidrs := defaultGeoCIDRs
+	if path := os.Getenv("GEOIP_CIDR_FILE"); path != "" {
+		loaded, err := loadGeoCIDRFile(path)
+		if err != nil {
+			logMessage("warn", "Error loading GeoIP CIDR file, using defaults: "+err.Error(), "")
+		} else {
+			cidrs = loaded
+		}
+	}
+
+	resolver, err := newStaticGeoResolver(cidrs)
+	if err != nil {
+		logMessage("warn", "Invalid GeoIP CIDR map, using defaults: "+err.Error(), "")
+		resolver, _ = newStaticGeoResolver(defaultGeoCIDRs)
+	}
+	return resolver
+}
+
+var geo = newGeoResolver()
+
+// firstSeenCountries acota la cardinalidad del contador: tienen label propio
+// los primeros GEOIP_MAX_COUNTRIES países vistos desde el arranque, no los
+// de más tráfico. Un país que aparece tarde queda en "other" aunque después
+// sea el que más requests envía.
+type firstSeenCountries struct {
+	limit int
+
+	mu   sync.Mutex
+	seen map[string]bool
+}
+
+var countries = newFirstSeenCountries()
+
+func newFirstSeenCountries() *firstSeenCountries {
+	c := &firstSeenCountries{limit: 20, seen: make(map[string]bool)}
+	if v, err := strconv.Atoi(os.Getenv("GEOIP_MAX_COUNTRIES")); err == nil && v > 0 {
+		c.limit = v
+	}
+	return c
+}
+
+func (c *firstSeenCountries) label(country string) string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.seen[country] {
+		return country
+	}
+	if len(c.seen) >= c.limit {
+		return "other"
+	}
+	c.seen[country] = true
+	return country
+}
+
+// resolveCountry devuelve el país de la IP, "private" para redes internas
+// y "unknown" si no se puede resolver.
+func resolveCountry(ipText string) string {
+	ip := net.ParseIP(ipText)
+	if ip == nil {
+		return "unknown"
+	}
+	if country := geo.Country(ip); country != "" {
+		return country
+	}
+	if ip.IsPrivate() || ip.IsLoopback() || ip.IsLinkLocalUnicast() {
+		return "private"
+	}
+	return "unknown"
+}
+
+type geoContextKey struct{}
+
+// countryFromContext devuelve el país que resolvió geoMiddleware.
+func countryFromContext(ctx context.Context) string {
+	if country, ok := ctx.Value(geoContextKey{}).(string); ok {
+		return country
+	}
+	return ""
+}
+
+// geoMiddleware resuelve el país del cliente, lo agrega al span y lo deja
+// en el contexto para que los logs de la request lo incluyan.
+func geoMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/metrics" {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		country := resolveCountry(clientIP(r))
+		requestsByCountry.WithLabelValues(countries.label(country)).Inc()
+		oteltrace.SpanFromContext(r.Context()).SetAttributes(attribute.String("client.geo.country_iso_code", country))
+
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), geoContextKey{}, country)))
+	})
+}
@@ -0,0 +1,237 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Consultas por servicio (label job) sobre las métricas HTTP comunes a app1 y app2
+const (
+	errorRateQuery = `sum by (job) (rate(http_requests_total{status_code=~"5.."}[5m])) / sum by (job) (rate(http_requests_total[5m]))`
+	p95Query       = `histogram_quantile(0.95, sum by (job, le) (rate(http_request_duration_seconds_bucket[5m])))`
+)
+
+var healthScore = prometheus.NewGaugeVec(
+	prometheus.GaugeOpts{
+		Name: "app1_health_score",
+		Help: "Composite health score (0-100) per service computed from Prometheus error rate and p95 latency",
+	},
+	[]string{"service"},
+)
+
+func init() {
+	prometheus.MustRegister(healthScore)
+	describeMetric("app1_health_score", metricMeta{Panel: "gauge", Unit: "none", Thresholds: []float64{50, 80}, LowerIsWorse: true})
+}
+
+type serviceHealth struct {
+	Service    string  `json:"service"`
+	ErrorRate  float64 `json:"error_rate"`
+	P95Seconds float64 `json:"p95_seconds"`
+	Score      float64 `json:"score"`
+}
+
+type healthReport struct {
+	UpdatedAt time.Time       `json:"updated_at"`
+	Overall   float64         `json:"overall"`
+	Services  []serviceHealth `json:"services"`
+}
+
+// healthScorer consulta Prometheus y pondera error rate y p95 a partes
+// iguales: cada componente vale 1 dentro del objetivo y cae a 0 al
+// alcanzar el error máximo o el doble del p95 objetivo.
+type healthScorer struct {
+	prometheusURL string
+	maxErrorRate  float64
+	p95Target     time.Duration
+	client        *http.Client
+
+	mu   sync.Mutex
+	last *healthReport
+}
+
+var scorer = newHealthScorer()
+
+func newHealthScorer() *healthScorer {
+	s := &healthScorer{
+		prometheusURL: strings.TrimRight(os.Getenv("PROMETHEUS_URL"), "/"),
+		maxErrorRate:  0.05,
+		p95Target:     500 * time.Millisecond,
+		client:        &http.Client{Timeout: 10 * time.Second},
+	}
+
+	if v := os.Getenv("HEALTH_MAX_ERROR_RATE"); v != "" {
+		if parsed, err := strconv.ParseFloat(v, 64); err == nil && parsed > 0 {
+			s.maxErrorRate = parsed
+		}
+	}
+	if v := os.Getenv("HEALTH_P95_TARGET"); v != "" {
+		if parsed, err := time.ParseDuration(v); err == nil && parsed > 0 {
+			s.p95Target = parsed
+		}
+	}
+
+	return s
+}
+
+func (s *healthScorer) enabled() bool {
+	return s.prometheusURL != ""
+}
+
+// queryVector ejecuta una consulta instantánea y devuelve el valor por job.
+func (s *healthScorer) queryVector(ctx context.Context, query string) (map[string]float64, error) {
+	start := time.Now()
+	values, err := s.doQuery(ctx, query)
+	observeDependency("prometheus", start, err)
+	return values, err
+}
+
+func (s *healthScorer) doQuery(ctx context.Context, query string) (map[string]float64, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, s.prometheusURL+"/api/v1/query?query="+url.QueryEscape(query), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var body struct {
+		Status string `json:"status"`
+		Error  string `json:"error"`
+		Data   struct {
+			Result []struct {
+				Metric map[string]string `json:"metric"`
+				Value  [2]interface{}    `json:"value"`
+			} `json:"result"`
+		} `json:"data"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return nil, fmt.Errorf("decoding Prometheus response: %w", err)
+	}
+	if body.Status != "success" {
+		return nil, fmt.Errorf("Prometheus query failed: %s", body.Error)
+	}
+
+	values := make(map[string]float64)
+	for _, sample := range body.Data.Result {
+		raw, ok := sample.Value[1].(string)
+		if !ok {
+			continue
+		}
+		value, err := strconv.ParseFloat(raw, 64)
+		if err != nil || math.IsNaN(value) {
+			continue
+		}
+		values[sample.Metric["job"]] = value
+	}
+
+	return values, nil
+}
+
+func (s *healthScorer) refresh(ctx context.Context) (*healthReport, error) {
+	errorRates, err := s.queryVector(ctx, errorRateQuery)
+	if err != nil {
+		return nil, err
+	}
+	latencies, err := s.queryVector(ctx, p95Query)
+	if err != nil {
+		return nil, err
+	}
+
+	services := make(map[string]bool)
+	for job := range errorRates {
+		services[job] = true
+	}
+	for job := range latencies {
+		services[job] = true
+	}
+
+	report := &healthReport{UpdatedAt: time.Now()}
+	for job := range services {
+		h := serviceHealth{Service: job, ErrorRate: errorRates[job], P95Seconds: latencies[job]}
+		h.Score = s.score(h.ErrorRate, h.P95Seconds)
+		report.Services = append(report.Services, h)
+
+		// El score global es el del servicio más degradado
+		if len(report.Services) == 1 || h.Score < report.Overall {
+			report.Overall = h.Score
+		}
+		healthScore.WithLabelValues(job).Set(h.Score)
+	}
+	sort.Slice(report.Services, func(i, j int) bool { return report.Services[i].Service < report.Services[j].Service })
+
+	s.mu.Lock()
+	s.last = report
+	s.mu.Unlock()
+
+	return report, nil
+}
+
+func (s *healthScorer) score(errorRate, p95 float64) float64 {
+	errorComponent := 1 - math.Min(errorRate/s.maxErrorRate, 1)
+
+	target := s.p95Target.Seconds()
+	latencyComponent := 1 - math.Min(math.Max(p95-target, 0)/target, 1)
+
+	return math.Round(100*(errorComponent+latencyComponent)/2*10) / 10
+}
+
+// run recalcula el score periódicamente para mantener el gauge al día.
+func (s *healthScorer) run(ctx context.Context) {
+	for {
+		refreshCtx, cancel := context.WithTimeout(ctx, 15*time.Second)
+		if _, err := s.refresh(refreshCtx); err != nil && ctx.Err() == nil {
+			logMessage("warn", "Health score refresh failed: "+err.Error(), "")
+		}
+		cancel()
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(30 * time.Second):
+		}
+	}
+}
+
+func healthScoreHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.Header().Set("Allow", "GET")
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if !scorer.enabled() {
+		http.Error(w, "PROMETHEUS_URL is not configured", http.StatusServiceUnavailable)
+		return
+	}
+
+	report, err := scorer.refresh(r.Context())
+	if err != nil {
+		// Si Prometheus no responde se sirve el último cálculo disponible
+		scorer.mu.Lock()
+		report = scorer.last
+		scorer.mu.Unlock()
+		if report == nil {
+			http.Error(w, "querying Prometheus: "+err.Error(), http.StatusBadGateway)
+			return
+		}
+		w.Header().Set("Warning", `110 app1 "stale health score"`)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(report)
+}
@@ -0,0 +1,104 @@
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Identificador único de esta instancia, generado al arrancar
+var instanceID = newInstanceID()
+
+var instanceInfo = prometheus.NewGaugeVec(
+	prometheus.GaugeOpts{
+		Name: "app1_instance_info",
+		Help: "Static information about this app1 instance; join on instance to break metrics down by instance_id",
+	},
+	[]string{"instance_id", "pod_ip"},
+)
+
+func init() {
+	prometheus.MustRegister(instanceInfo)
+	instanceInfo.WithLabelValues(instanceID, os.Getenv("POD_IP")).Set(1)
+}
+
+// newInstanceID genera un UUID v4.
+func newInstanceID() string {
+	var b [16]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		return fmt.Sprintf("pid-%d", os.Getpid())
+	}
+	b[6] = (b[6] & 0x0f) | 0x40
+	b[8] = (b[8] & 0x3f) | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:])
+}
+
+type peer struct {
+	Address string `json:"address"`
+	Self    bool   `json:"self"`
+}
+
+type peersResponse struct {
+	InstanceID string `json:"instance_id"`
+	Source     string `json:"source"`
+	Peers      []peer `json:"peers"`
+}
+
+// discoverPeers lista las instancias hermanas: primero la lista estática de
+// PEERS y, si no hay, las IPs que resuelve PEER_DNS (un Service headless).
+func discoverPeers(ctx context.Context) (string, []string, error) {
+	if static := os.Getenv("PEERS"); static != "" {
+		var addrs []string
+		for _, addr := range strings.Split(static, ",") {
+			if addr = strings.TrimSpace(addr); addr != "" {
+				addrs = append(addrs, addr)
+			}
+		}
+		return "static", addrs, nil
+	}
+
+	if name := os.Getenv("PEER_DNS"); name != "" {
+		addrs, err := net.DefaultResolver.LookupHost(ctx, name)
+		if err != nil {
+			return "dns", nil, err
+		}
+		sort.Strings(addrs)
+		return "dns", addrs, nil
+	}
+
+	return "none", nil, nil
+}
+
+func peersHandler(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), 2*time.Second)
+	defer cancel()
+
+	source, addrs, err := discoverPeers(ctx)
+	if err != nil {
+		logMessage("warn", "Peer discovery failed: "+err.Error(), "")
+		http.Error(w, "peer discovery failed", http.StatusBadGateway)
+		return
+	}
+
+	podIP := os.Getenv("POD_IP")
+	response := peersResponse{InstanceID: instanceID, Source: source, Peers: []peer{}}
+	for _, addr := range addrs {
+		host := addr
+		if h, _, err := net.SplitHostPort(addr); err == nil {
+			host = h
+		}
+		response.Peers = append(response.Peers, peer{Address: addr, Self: podIP != "" && host == podIP})
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
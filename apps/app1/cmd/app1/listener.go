@@ -0,0 +1,87 @@
+package main
+
+import (
+	"net"
+	"net/http"
+	"os"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var (
+	connectionsTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "app1_connections_total",
+			Help: "Accepted client connections by address family",
+		},
+		[]string{"family"},
+	)
+
+	openConnections = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "app1_open_connections",
+			Help: "Currently open client connections by address family",
+		},
+		[]string{"family"},
+	)
+)
+
+func init() {
+	prometheus.MustRegister(connectionsTotal)
+	prometheus.MustRegister(openConnections)
+}
+
+// listen abre el listener HTTP. BIND_ADDRESS vacío escucha en todas las
+// interfaces; con LISTEN_NETWORK=tcp (default) el socket es dual-stack
+// donde el sistema lo soporta, tcp4/tcp6 lo restringen a una familia.
+func listen(port string) (net.Listener, error) {
+	network := os.Getenv("LISTEN_NETWORK")
+	if network == "" {
+		network = "tcp"
+	}
+
+	return net.Listen(network, net.JoinHostPort(os.Getenv("BIND_ADDRESS"), port))
+}
+
+// listenUnix abre un socket unix adicional si UNIX_SOCKET está definido,
+// para comparar el overhead de transporte con procesos en el mismo host.
+func listenUnix() (net.Listener, error) {
+	path := os.Getenv("UNIX_SOCKET")
+	if path == "" {
+		return nil, nil
+	}
+
+	// Un socket que quedó de una ejecución anterior impide el bind
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+	return net.Listen("unix", path)
+}
+
+// addressFamily clasifica una dirección remota en ipv4, ipv6 o unix.
+// Las direcciones IPv4 mapeadas en IPv6 cuentan como ipv4.
+func addressFamily(addr net.Addr) string {
+	switch a := addr.(type) {
+	case *net.TCPAddr:
+		if a.IP.To4() != nil {
+			return "ipv4"
+		}
+		return "ipv6"
+	case *net.UnixAddr:
+		return "unix"
+	default:
+		return "other"
+	}
+}
+
+// trackConnState lleva la cuenta de conexiones abiertas por familia.
+func trackConnState(conn net.Conn, state http.ConnState) {
+	family := addressFamily(conn.RemoteAddr())
+	switch state {
+	case http.StateNew:
+		connectionsTotal.WithLabelValues(family).Inc()
+		openConnections.WithLabelValues(family).Inc()
+	case http.StateClosed, http.StateHijacked:
+		openConnections.WithLabelValues(family).Dec()
+	}
+}
@@ -7,13 +7,18 @@ import (
 	"log"
 	"math/rand"
 	"net/http"
+	"net/url"
 	"os"
+	"os/signal"
+	"strings"
+	"syscall"
 	"time"
 
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
 	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
 	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
 	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
 	"go.opentelemetry.io/otel/sdk/resource"
 	"go.opentelemetry.io/otel/sdk/trace"
@@ -61,6 +66,9 @@ type Response struct {
 	Message   string    `json:"message"`
 	Timestamp time.Time `json:"timestamp"`
 	TraceID   string    `json:"trace_id"`
+	// Respuesta parcial: dependencias opcionales que fallaron y se omitieron
+	Partial              bool     `json:"partial,omitempty"`
+	DegradedDependencies []string `json:"degraded_dependencies,omitempty"`
 }
 
 func init() {
@@ -68,6 +76,9 @@ func init() {
 	prometheus.MustRegister(httpDuration)
 	prometheus.MustRegister(businessMetric)
 	prometheus.MustRegister(errorRate)
+
+	describeMetric("http_requests_total", metricMeta{Unit: "reqps"})
+	describeMetric("http_request_duration_seconds", metricMeta{Unit: "s", Thresholds: []float64{0.5, 1}})
 }
 
 func setupTracing() (*trace.TracerProvider, error) {
@@ -76,6 +87,11 @@ func setupTracing() (*trace.TracerProvider, error) {
 		tempoEndpoint = "http://tempo:4318"
 	}
 
+	// WithEndpoint espera host:puerto, pero TEMPO_ENDPOINT suele venir como URL
+	if u, err := url.Parse(tempoEndpoint); err == nil && u.Host != "" {
+		tempoEndpoint = u.Host
+	}
+
 	exporter, err := otlptracehttp.New(
 		context.Background(),
 		otlptracehttp.WithEndpoint(tempoEndpoint),
@@ -85,12 +101,32 @@ func setupTracing() (*trace.TracerProvider, error) {
 		return nil, err
 	}
 
+	// Buffer en disco para no perder spans mientras Tempo no responde
+	spool, err := newSpanSpool()
+	if err != nil {
+		return nil, err
+	}
+	spanBuffer = spool
+	if spool != nil {
+		spool.start(instrumentedExporter{SpanExporter: exporter}.export)
+	}
+
+	// Acá y no en newRedactor: el log de aplicación también pasa por el redactor
+	if telemetryRedactor.enabled() && telemetryRedactor.randomSalt {
+		logMessage("warn", "TELEMETRY_REDACT_SALT is not set, hashing with a random per-process salt", "")
+	}
+
 	tp := trace.NewTracerProvider(
-		trace.WithBatcher(exporter),
+		trace.WithSampler(traceSampler),
+		trace.WithSpanProcessor(newBoundedBatcher(redactingExporter{
+			SpanExporter: instrumentedExporter{SpanExporter: exporter, spool: spool},
+			redactor:     telemetryRedactor,
+		})),
 		trace.WithResource(resource.NewWithAttributes(
 			semconv.SchemaURL,
 			semconv.ServiceNameKey.String("app1"),
-			semconv.ServiceVersionKey.String("1.0.0"),
+			semconv.ServiceVersionKey.String(serviceVersion),
+			semconv.ServiceInstanceIDKey.String(instanceID),
 		)),
 	)
 
@@ -99,16 +135,42 @@ func setupTracing() (*trace.TracerProvider, error) {
 }
 
 func logMessage(level, message string, traceID string) {
+	logWithFields(level, message, traceID, nil)
+}
+
+// logWithFields agrega campos estructurados adicionales a la entrada de log.
+func logWithFields(level, message string, traceID string, fields map[string]interface{}) {
+	if !logEnabled(level) {
+		return
+	}
+	writeLog(level, message, traceID, fields)
+}
+
+// writeLog escribe la entrada sin filtrar por LOG_LEVEL.
+func writeLog(level, message string, traceID string, fields map[string]interface{}) {
 	logEntry := map[string]interface{}{
-		"timestamp": time.Now().Format(time.RFC3339),
-		"level":     level,
-		"service":   "app1",
-		"message":   message,
-		"trace_id":  traceID,
+		"timestamp":   time.Now().Format(time.RFC3339),
+		"level":       level,
+		"service":     "app1",
+		"instance_id": instanceID,
+		"message":     message,
+		"trace_id":    traceID,
 	}
+	for k, v := range fields {
+		if _, reserved := logEntry[k]; !reserved {
+			logEntry[k] = v
+		}
+	}
+	telemetryRedactor.logFields(logEntry)
 	
-	logJSON, _ := json.Marshal(logEntry)
-	fmt.Println(string(logJSON))
+	logJSON, err := json.Marshal(logEntry)
+	if err != nil {
+		logFailures.WithLabelValues("encode").Inc()
+		return
+	}
+	if _, err := fmt.Println(string(logJSON)); err != nil {
+		logFailures.WithLabelValues("write").Inc()
+	}
 }
 
 func healthHandler(w http.ResponseWriter, r *http.Request) {
@@ -125,8 +187,7 @@ func healthHandler(w http.ResponseWriter, r *http.Request) {
 		TraceID:   traceID,
 	}
 	
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(response)
+	writeResponse(w, r, "/health", http.StatusOK, response)
 	
 	httpRequestsTotal.WithLabelValues(r.Method, "/health", "200").Inc()
 	httpDuration.WithLabelValues(r.Method, "/health").Observe(time.Since(start).Seconds())
@@ -166,13 +227,32 @@ func dataHandler(w http.ResponseWriter, r *http.Request) {
 		TraceID:   traceID,
 	}
 	
-	// Simular llamada a otro servicio
-	ctx, callSpan := otel.Tracer("app1").Start(ctx, "external_call")
-	time.Sleep(time.Duration(rand.Intn(50)) * time.Millisecond)
-	callSpan.End()
+	// Simular llamadas a otros servicios; las opcionales pueden faltar
+	degraded, failed, err := callDependencies(ctx,
+		dependencyCall{span: "external_call", dependency: "external-service", maxLatency: 50 * time.Millisecond},
+		dependencyCall{span: "recommendations_call", dependency: "recommendations", maxLatency: 30 * time.Millisecond},
+	)
+	if err != nil {
+		logMessage("error", "Critical dependency "+failed+" failed: "+err.Error(), traceID)
+		errorRate.WithLabelValues("dependency").Inc()
+		w.WriteHeader(http.StatusBadGateway)
+		httpRequestsTotal.WithLabelValues(r.Method, "/data", "502").Inc()
+		return
+	}
+	
+	completeness := "full"
+	if len(degraded) > 0 {
+		completeness = "partial"
+		response.Partial = true
+		response.DegradedDependencies = degraded
+		w.Header().Set("X-Partial-Response", strings.Join(degraded, ","))
+		span.SetAttributes(attribute.StringSlice("app1.degraded_dependencies", degraded))
+		logMessage("warn", "Serving partial response without "+strings.Join(degraded, ", "), traceID)
+	}
+	span.SetAttributes(attribute.Bool("app1.partial_response", response.Partial))
+	responseCompleteness.WithLabelValues("/data", completeness).Inc()
 	
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(response)
+	writeResponse(w, r, "/data", http.StatusOK, response)
 	
 	httpRequestsTotal.WithLabelValues(r.Method, "/data", "200").Inc()
 	httpDuration.WithLabelValues(r.Method, "/data").Observe(time.Since(start).Seconds())
@@ -198,8 +278,7 @@ func slowHandler(w http.ResponseWriter, r *http.Request) {
 		TraceID:   traceID,
 	}
 	
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(response)
+	writeResponse(w, r, "/slow", http.StatusOK, response)
 	
 	httpRequestsTotal.WithLabelValues(r.Method, "/slow", "200").Inc()
 	httpDuration.WithLabelValues(r.Method, "/slow").Observe(time.Since(start).Seconds())
@@ -207,61 +286,136 @@ func slowHandler(w http.ResponseWriter, r *http.Request) {
 }
 
 // Simulador de métricas de negocio
-func metricsSimulator() {
-	ticker := time.NewTicker(10 * time.Second)
-	defer ticker.Stop()
+func metricsSimulator(ctx context.Context) {
+	interval := labClock.Interval(10 * time.Second)
+	throttler := newResourceThrottler()
+	
+	timer := time.NewTimer(interval)
+	defer timer.Stop()
 	
 	for {
 		select {
-		case <-ticker.C:
-			businessMetric.WithLabelValues("cpu_usage").Set(rand.Float64() * 100)
-			businessMetric.WithLabelValues("memory_usage").Set(rand.Float64() * 100)
-			businessMetric.WithLabelValues("active_connections").Set(rand.Float64() * 50)
+		case <-ctx.Done():
+			return
+		case <-timer.C:
+			// En modo comprimido la carga sigue el patrón diario simulado
+			load := 1.0
+			if labClock.compressed() {
+				load = 0.2 + 0.8*dailyLoad(labClock.Now())
+			}
+			
+			businessMetric.WithLabelValues("cpu_usage").Set(rand.Float64() * 100 * load)
+			businessMetric.WithLabelValues("memory_usage").Set(rand.Float64() * 100 * load)
+			businessMetric.WithLabelValues("active_connections").Set(rand.Float64() * 50 * load)
 			
 			if rand.Float32() < 0.05 {
 				errorRate.WithLabelValues("background").Inc()
 				logMessage("warn", "Background task warning", "")
 			}
+			
+			timer.Reset(throttler.Next(interval))
 		}
 	}
 }
 
+// newHandler arma las rutas y la cadena de middlewares que sirve app1.
+func newHandler() http.Handler {
+	// Configurar rutas con instrumentación OpenTelemetry
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", promhttp.Handler())
+	mux.HandleFunc("/health", healthHandler)
+	mux.HandleFunc("/data", dataHandler)
+	mux.HandleFunc("/slow", slowHandler)
+	mux.HandleFunc("/admin/tracing/sampling", samplingHandler)
+	mux.HandleFunc("/peers", peersHandler)
+	mux.HandleFunc("/admin/health-score", healthScoreHandler)
+	mux.HandleFunc("/admin/alerts", alertWebhookHandler)
+	mux.HandleFunc("/usage", usageHandler)
+	mux.HandleFunc("/admin/topology", topologyHandler)
+	mux.HandleFunc("/admin/config", configHandler)
+	mux.HandleFunc("/admin/metrics/metadata", metricMetadataHandler)
+	
+	// Middlewares propios, del más interno al más externo
+	var handler http.Handler = autoscalingMiddleware(mux)
+	handler = sliMiddleware(mux, handler)
+	handler = quotaMiddleware(handler)
+	handler = startupMiddleware(handler)
+	handler = botMiddleware(handler)
+	handler = accessLogMiddleware(mux, handler)
+	handler = geoMiddleware(handler)
+	
+	// Envolver con instrumentación OpenTelemetry
+	handler = otelhttp.NewHandler(handler, "app1")
+	return handler
+}
+
 func main() {
 	// Configurar trazas
+	tracingStart := time.Now()
 	tp, err := setupTracing()
 	if err != nil {
 		log.Fatalf("Error setting up tracing: %v", err)
 	}
-	defer func() {
-		if err := tp.Shutdown(context.Background()); err != nil {
-			log.Printf("Error shutting down tracer provider: %v", err)
-		}
-	}()
+	startup.phase("tracing", tracingStart)
+
+	// SIGTERM (rolling restart de Kubernetes) o SIGINT inician el apagado
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGTERM, syscall.SIGINT)
+	defer stop()
 
 	// Iniciar simulador de métricas en background
-	go metricsSimulator()
+	go metricsSimulator(ctx)
 	
-	// Configurar rutas con instrumentación OpenTelemetry
-	mux := http.NewServeMux()
-	mux.Handle("/metrics", promhttp.Handler())
-	mux.HandleFunc("/health", healthHandler)
-	mux.HandleFunc("/data", dataHandler)
-	mux.HandleFunc("/slow", slowHandler)
+	if scorer.enabled() {
+		go scorer.run(ctx)
+	}
+	go scaling.run(ctx)
+	go watchConfig(ctx)
 	
-	// Envolver con instrumentación OpenTelemetry
-	handler := otelhttp.NewHandler(mux, "app1")
+	handler := newHandler()
 	
 	port := os.Getenv("PORT")
 	if port == "" {
 		port = "8080"
 	}
 	
-	logMessage("info", "App1 starting on port "+port, "")
+	startup.warmupDependencies()
+	
+	listenStart := time.Now()
+	listener, err := listen(port)
+	if err != nil {
+		log.Fatalf("Error opening listener: %v", err)
+	}
+	startup.phase("listen", listenStart)
+	startup.emitSpans()
+	
+	logMessage("info", "App1 instance "+instanceID+" listening on "+listener.Addr().String(), "")
 	
 	server := &http.Server{
-		Addr:    ":" + port,
-		Handler: handler,
+		Handler:   handler,
+		ConnState: trackConnState,
+	}
+	
+	unixListener, err := listenUnix()
+	if err != nil {
+		log.Fatalf("Error opening unix socket: %v", err)
 	}
 	
-	log.Fatal(server.ListenAndServe())
+	serveErr := make(chan error, 2)
+	if unixListener != nil {
+		logMessage("info", "App1 also listening on unix socket "+unixListener.Addr().String(), "")
+		go func() {
+			serveErr <- server.Serve(unixListener)
+		}()
+	}
+	go func() {
+		serveErr <- server.Serve(listener)
+	}()
+	
+	select {
+	case err := <-serveErr:
+		log.Fatal(err)
+	case <-ctx.Done():
+		stop()
+		gracefulShutdown(server, tp)
+	}
 }
\ No newline at end of file
@@ -0,0 +1,137 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// Rutas de la cadena completa con el status esperado; 0 acepta cualquiera
+// (/data falla a propósito, /admin/health-score no tiene Prometheus). /slow
+// queda afuera: tarda segundos y no agrega caminos de código.
+var concurrentRoutes = []struct {
+	method, path, body string
+	want               int
+}{
+	{http.MethodGet, "/data", "", 0},
+	{http.MethodGet, "/health", "", http.StatusOK},
+	{http.MethodGet, "/metrics", "", http.StatusOK},
+	{http.MethodGet, "/peers", "", http.StatusOK},
+	{http.MethodGet, "/usage", "", http.StatusOK},
+	{http.MethodGet, "/admin/config", "", http.StatusOK},
+	{http.MethodGet, "/admin/topology", "", http.StatusOK},
+	{http.MethodGet, "/admin/health-score", "", 0},
+	{http.MethodGet, "/admin/tracing/sampling", "", http.StatusOK},
+	{http.MethodGet, "/admin/metrics/metadata", "", http.StatusOK},
+	{http.MethodPost, "/admin/alerts", `{"alerts":[{"status":"firing","labels":{"alertname":"Test","severity":"info"}}]}`, http.StatusNoContent},
+	{http.MethodGet, "/not-found", "", http.StatusNotFound},
+}
+
+// TestConcurrentRoutes sirve la cadena de middlewares de app1 con httptest y
+// le envía requests concurrentes a todas las rutas mientras se recarga la
+// configuración y se cambia el muestreo en caliente. Con go test -race
+// detecta data races en el estado compartido entre requests.
+func TestConcurrentRoutes(t *testing.T) {
+	configFile := filepath.Join(t.TempDir(), "app1.conf")
+	t.Setenv("CONFIG_FILE", configFile)
+	accessLog.out = io.Discard
+
+	server := httptest.NewServer(newHandler())
+	defer server.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	var background sync.WaitGroup
+	background.Add(2)
+	go func() {
+		defer background.Done()
+		scaling.run(ctx)
+	}()
+	go func() {
+		defer background.Done()
+		metricsSimulator(ctx)
+	}()
+	defer func() {
+		cancel()
+		background.Wait()
+	}()
+
+	const workers, requestsPerWorker = 8, 60
+
+	var wg sync.WaitGroup
+	errs := make(chan error, workers*requestsPerWorker)
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			for i := 0; i < requestsPerWorker; i++ {
+				route := concurrentRoutes[(w+i)%len(concurrentRoutes)]
+
+				req, err := http.NewRequest(route.method, server.URL+route.path, strings.NewReader(route.body))
+				if err != nil {
+					errs <- err
+					continue
+				}
+				req.Header.Set("User-Agent", "Mozilla/5.0 (X11; Linux x86_64)")
+				req.Header.Set("Accept-Language", "es-AR")
+				req.Header.Set("X-Forwarded-For", fmt.Sprintf("100.64.%d.%d", w, 1+i))
+				if i%3 == 0 {
+					req.Header.Set("X-API-Key", fmt.Sprintf("key-%d", w%3))
+				}
+
+				resp, err := server.Client().Do(req)
+				if err != nil {
+					errs <- fmt.Errorf("%s %s: %w", route.method, route.path, err)
+					continue
+				}
+				io.Copy(io.Discard, resp.Body)
+				resp.Body.Close()
+
+				if route.want != 0 && resp.StatusCode != route.want {
+					errs <- fmt.Errorf("%s %s: status %d, want %d", route.method, route.path, resp.StatusCode, route.want)
+				}
+			}
+		}(w)
+	}
+
+	// Cambios de configuración en paralelo con el tráfico
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 10; i++ {
+			config := fmt.Sprintf("LOG_LEVEL=error\nACCESS_LOG_SAMPLE=0.%d\nBOT_ACTION=tag\nCLIENT_DAILY_QUOTA=100000\nCLIENT_QUOTAS=key-1=%d\n", i, 1000+i)
+			if err := os.WriteFile(configFile, []byte(config), 0o644); err != nil {
+				errs <- err
+				return
+			}
+			reloadConfig("test")
+
+			req, err := http.NewRequest(http.MethodPut, server.URL+"/admin/tracing/sampling", strings.NewReader(fmt.Sprintf(`{"ratio":0.%d}`, i)))
+			if err != nil {
+				errs <- err
+				return
+			}
+			resp, err := server.Client().Do(req)
+			if err != nil {
+				errs <- err
+				return
+			}
+			resp.Body.Close()
+			if resp.StatusCode != http.StatusOK {
+				errs <- fmt.Errorf("PUT /admin/tracing/sampling: status %d", resp.StatusCode)
+			}
+		}
+	}()
+
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Error(err)
+	}
+}
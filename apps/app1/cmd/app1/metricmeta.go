@@ -0,0 +1,30 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+)
+
+// metricMeta indica cómo graficar una métrica: tipo de panel y unidad de
+// Grafana, y umbrales en orden ascendente. labctl dashboard la lee de
+// /admin/metrics/metadata; lo que no se describe acá lo deduce del sufijo.
+type metricMeta struct {
+	Panel      string    `json:"panel,omitempty"`
+	Unit       string    `json:"unit,omitempty"`
+	Thresholds []float64 `json:"thresholds,omitempty"`
+	// Umbrales donde un valor más bajo es peor (health score, completitud)
+	LowerIsWorse bool `json:"lower_is_worse,omitempty"`
+}
+
+// Solo se escribe desde los init(), antes de servir requests
+var metricMetadata = make(map[string]metricMeta)
+
+// describeMetric se llama junto al MustRegister de la métrica.
+func describeMetric(name string, meta metricMeta) {
+	metricMetadata[name] = meta
+}
+
+func metricMetadataHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(metricMetadata)
+}
@@ -0,0 +1,313 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var (
+	clientRequests = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "app1_client_requests_total",
+			Help: "Metered requests per API client",
+		},
+		[]string{"client"},
+	)
+
+	quotaExceeded = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "app1_client_quota_exceeded_total",
+			Help: "Requests rejected with 429 because the client exhausted its daily quota",
+		},
+		[]string{"client"},
+	)
+
+	quotaUsage = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "app1_client_quota_usage_ratio",
+			Help: "Fraction of the daily quota used by each client configured in CLIENT_QUOTAS",
+		},
+		[]string{"client"},
+	)
+)
+
+func init() {
+	prometheus.MustRegister(clientRequests)
+	prometheus.MustRegister(quotaExceeded)
+	prometheus.MustRegister(quotaUsage)
+	describeMetric("app1_client_quota_usage_ratio", metricMeta{Unit: "percentunit", Thresholds: []float64{0.8, 1}})
+}
+
+// Clientes distintos que se siguen por día. Los que llegan con el cupo lleno
+// comparten el bucket overflowClient, con su propia cuota, hasta el día
+// siguiente; las keys de CLIENT_QUOTAS se siguen siempre.
+const maxTrackedClients = 1000
+
+// overflowClient no choca con clientID, que siempre devuelve "key-..." o "anonymous"
+const overflowClient = "other"
+
+// Rutas que no consumen cuota (scrapes, probes y administración)
+var quotaExemptPrefixes = []string{"/metrics", "/health", "/admin/", "/usage"}
+
+// quotaMeter cuenta requests por API key y día simulado. Las keys nunca
+// aparecen en métricas ni respuestas: se identifican por un hash corto.
+type quotaMeter struct {
+	settings atomic.Pointer[quotaSettings]
+
+	mu             sync.Mutex
+	day            string
+	usage          map[string]int64
+	overflow       map[string]bool // keys que hoy cuentan en overflowClient
+	overflowLogged bool
+}
+
+var meter = newQuotaMeter()
+
+// quotaSettings se reemplaza entero al recargar la configuración; el uso
+// acumulado del día se conserva.
+type quotaSettings struct {
+	defaultQuota  int64
+	overflowQuota int64
+	quotas        map[string]int64
+}
+
+func newQuotaMeter() *quotaMeter {
+	m := &quotaMeter{usage: make(map[string]int64), overflow: make(map[string]bool)}
+	m.settings.Store(loadQuotaSettings())
+	return m
+}
+
+func loadQuotaSettings() *quotaSettings {
+	s := &quotaSettings{quotas: make(map[string]int64)}
+
+	if v, err := strconv.ParseInt(getConfig("CLIENT_DAILY_QUOTA"), 10, 64); err == nil && v > 0 {
+		s.defaultQuota = v
+	}
+
+	// Cuota del bucket compartido; por defecto la misma que una sola key
+	s.overflowQuota = s.defaultQuota
+	if v, err := strconv.ParseInt(getConfig("CLIENT_OVERFLOW_QUOTA"), 10, 64); err == nil && v > 0 {
+		s.overflowQuota = v
+	}
+
+	// CLIENT_QUOTAS=key1=1000,key2=500
+	for _, entry := range strings.Split(getConfig("CLIENT_QUOTAS"), ",") {
+		key, value, ok := strings.Cut(strings.TrimSpace(entry), "=")
+		if !ok {
+			continue
+		}
+		if quota, err := strconv.ParseInt(value, 10, 64); err == nil && quota > 0 {
+			s.quotas[key] = quota
+		}
+	}
+
+	return s
+}
+
+func clientID(apiKey string) string {
+	if apiKey == "" {
+		return "anonymous"
+	}
+	sum := sha256.Sum256([]byte(apiKey))
+	return "key-" + hex.EncodeToString(sum[:4])
+}
+
+// quotaFor devuelve la cuota diaria de la key; las requests sin key no se limitan.
+func (m *quotaMeter) quotaFor(apiKey string) int64 {
+	if apiKey == "" {
+		return 0
+	}
+	settings := m.settings.Load()
+	if quota, ok := settings.quotas[apiKey]; ok {
+		return quota
+	}
+	return settings.defaultQuota
+}
+
+// configured indica si la key tiene cuota propia en CLIENT_QUOTAS.
+func (m *quotaMeter) configured(apiKey string) bool {
+	_, ok := m.settings.Load().quotas[apiKey]
+	return ok
+}
+
+// take registra una request y devuelve el bucket donde se contó, el uso
+// tras contarla y la cuota aplicada (0 = sin límite). Las requests
+// rechazadas no suman al uso. Si el cliente no entró en maxTrackedClients
+// se cuenta y se limita en overflowClient.
+func (m *quotaMeter) take(client string, quota int64, configured bool) (bucket string, used, limit int64, allowed bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.rollover()
+
+	bucket, limit = client, quota
+	if m.overflowed(client, configured) {
+		bucket, limit = overflowClient, m.settings.Load().overflowQuota
+	}
+
+	used = m.usage[bucket]
+	if limit > 0 && used >= limit {
+		return bucket, used, limit, false
+	}
+
+	used++
+	m.usage[bucket] = used
+	return bucket, used, limit, true
+}
+
+// overflowed indica si el cliente cuenta hoy en overflowClient, y lo anota
+// ahí si es nuevo y ya no hay lugar; requiere m.mu.
+func (m *quotaMeter) overflowed(client string, configured bool) bool {
+	if configured || client == clientID("") {
+		return false
+	}
+	if m.overflow[client] {
+		return true
+	}
+	if _, ok := m.usage[client]; ok || len(m.usage) < maxTrackedClients {
+		return false
+	}
+
+	m.overflow[client] = true
+	if !m.overflowLogged {
+		m.overflowLogged = true
+		logMessage("warn", fmt.Sprintf("Tracking %d clients today, new API keys share the %q quota until the daily reset", maxTrackedClients, overflowClient), "")
+	}
+	return true
+}
+
+// rollover reinicia los contadores al cambiar el día; requiere m.mu.
+func (m *quotaMeter) rollover() {
+	if day := labClock.Now().Format("2006-01-02"); day != m.day {
+		m.day = day
+		m.usage = make(map[string]int64)
+		m.overflow = make(map[string]bool)
+		m.overflowLogged = false
+	}
+}
+
+// metricLabel limita la cardinalidad: solo las keys configuradas en
+// CLIENT_QUOTAS tienen label propio.
+func (m *quotaMeter) metricLabel(apiKey string) string {
+	if apiKey == "" {
+		return "anonymous"
+	}
+	if m.configured(apiKey) {
+		return clientID(apiKey)
+	}
+	return "other"
+}
+
+// nextReset devuelve la próxima medianoche del reloj del lab.
+func nextReset() time.Time {
+	now := labClock.Now()
+	return time.Date(now.Year(), now.Month(), now.Day()+1, 0, 0, 0, 0, now.Location())
+}
+
+func quotaMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		for _, prefix := range quotaExemptPrefixes {
+			if strings.HasPrefix(r.URL.Path, prefix) {
+				next.ServeHTTP(w, r)
+				return
+			}
+		}
+
+		apiKey := r.Header.Get("X-API-Key")
+		client := clientID(apiKey)
+		quota := meter.quotaFor(apiKey)
+
+		label := meter.metricLabel(apiKey)
+
+		bucket, used, limit, allowed := meter.take(client, quota, meter.configured(apiKey))
+		clientRequests.WithLabelValues(label).Inc()
+
+		if limit > 0 {
+			remaining := limit - used
+			if remaining < 0 {
+				remaining = 0
+			}
+			reset := nextReset()
+
+			w.Header().Set("X-RateLimit-Limit", strconv.FormatInt(limit, 10))
+			w.Header().Set("X-RateLimit-Remaining", strconv.FormatInt(remaining, 10))
+			w.Header().Set("X-RateLimit-Reset", strconv.FormatInt(reset.Unix(), 10))
+			// "other" mezcla clientes con cuotas distintas, no tiene ratio
+			if label != "other" {
+				quotaUsage.WithLabelValues(label).Set(float64(used) / float64(limit))
+			}
+
+			if !allowed {
+				quotaExceeded.WithLabelValues(label).Inc()
+				logWithFields("warn", "Daily quota exhausted for client "+bucket, "", map[string]interface{}{
+					"client": bucket,
+					"quota":  limit,
+				})
+
+				// Retry-After en segundos reales, aunque el día sea simulado
+				retry := time.Duration(float64(reset.Sub(labClock.Now())) / labClock.factor)
+				w.Header().Set("Retry-After", strconv.Itoa(int(retry.Seconds())+1))
+				http.Error(w, "daily quota exceeded", http.StatusTooManyRequests)
+				return
+			}
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+type clientUsage struct {
+	Client    string `json:"client"`
+	Requests  int64  `json:"requests"`
+	Quota     int64  `json:"quota,omitempty"`
+	Remaining *int64 `json:"remaining,omitempty"`
+	// Fuera de maxTrackedClients: hoy cuenta en el bucket compartido
+	Shared bool `json:"shared,omitempty"`
+}
+
+type usageResponse struct {
+	Day     string        `json:"day"`
+	ResetAt time.Time     `json:"reset_at"`
+	Clients []clientUsage `json:"clients"`
+}
+
+// usageHandler devuelve el uso del día del cliente que llama: el de su
+// X-API-Key, o el de "anonymous" si no envía ninguna. Nunca el de otras keys.
+func usageHandler(w http.ResponseWriter, r *http.Request) {
+	apiKey := r.Header.Get("X-API-Key")
+	caller := clientID(apiKey)
+	usage := clientUsage{Client: caller}
+
+	meter.mu.Lock()
+	meter.rollover()
+	day := meter.day
+	bucket, quota := caller, meter.quotaFor(apiKey)
+	if meter.overflow[caller] {
+		bucket, quota = overflowClient, meter.settings.Load().overflowQuota
+		usage.Shared = true
+	}
+	usage.Requests = meter.usage[bucket]
+	meter.mu.Unlock()
+
+	if quota > 0 {
+		remaining := quota - usage.Requests
+		if remaining < 0 {
+			remaining = 0
+		}
+		usage.Quota = quota
+		usage.Remaining = &remaining
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(usageResponse{Day: day, ResetAt: nextReset(), Clients: []clientUsage{usage}})
+}
@@ -0,0 +1,168 @@
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/sdk/resource"
+	"go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+const (
+	redactActionDrop = "drop"
+	redactActionHash = "hash"
+	redactActionMask = "redact"
+
+	redactedValue = "[REDACTED]"
+
+	// Claves con datos personales que se protegen si no se configura otra cosa
+	defaultRedactRules = "client_ip=hash,email=hash,user.email=hash,enduser.id=hash,http.client_ip=hash,net.sock.peer.addr=hash"
+)
+
+var redactionsTotal = prometheus.NewCounterVec(
+	prometheus.CounterOpts{
+		Name: "app1_telemetry_redactions_total",
+		Help: "Span and log attributes redacted before leaving the process",
+	},
+	[]string{"signal", "key", "action"},
+)
+
+var telemetryRedactor = newRedactor()
+
+func init() {
+	prometheus.MustRegister(redactionsTotal)
+}
+
+// redactor aplica a spans y logs las reglas clave=acción de TELEMETRY_REDACT,
+// donde la acción es hash, redact o drop.
+type redactor struct {
+	rules map[string]string
+	salt  string
+	// Sin TELEMETRY_REDACT_SALT se usa uno aleatorio por proceso: un hash sin
+	// salt de una IP se revierte probando las 2^32 posibles.
+	randomSalt bool
+}
+
+func newRedactor() *redactor {
+	spec, ok := os.LookupEnv("TELEMETRY_REDACT")
+	if !ok {
+		spec = defaultRedactRules
+	}
+
+	r := &redactor{rules: map[string]string{}, salt: os.Getenv("TELEMETRY_REDACT_SALT")}
+	if r.salt == "" {
+		salt := make([]byte, 16)
+		if _, err := rand.Read(salt); err != nil {
+			panic("reading random salt: " + err.Error())
+		}
+		r.salt, r.randomSalt = hex.EncodeToString(salt), true
+	}
+	for _, rule := range strings.Split(spec, ",") {
+		key, action, found := strings.Cut(strings.TrimSpace(rule), "=")
+		if key == "" {
+			continue
+		}
+		if !found {
+			action = redactActionMask
+		}
+		switch action {
+		case redactActionDrop, redactActionHash, redactActionMask:
+			r.rules[key] = action
+		}
+	}
+
+	return r
+}
+
+func (r *redactor) enabled() bool {
+	return len(r.rules) > 0
+}
+
+// configuredSalt devuelve el salt de TELEMETRY_REDACT_SALT, vacío si es aleatorio.
+func (r *redactor) configuredSalt() string {
+	if r.randomSalt {
+		return ""
+	}
+	return r.salt
+}
+
+func (r *redactor) hash(value string) string {
+	sum := sha256.Sum256([]byte(r.salt + value))
+	return "sha256:" + hex.EncodeToString(sum[:8])
+}
+
+func (r *redactor) attributes(kvs []attribute.KeyValue) []attribute.KeyValue {
+	out := make([]attribute.KeyValue, 0, len(kvs))
+	for _, kv := range kvs {
+		action, ok := r.rules[string(kv.Key)]
+		if !ok {
+			out = append(out, kv)
+			continue
+		}
+
+		redactionsTotal.WithLabelValues("traces", string(kv.Key), action).Inc()
+		switch action {
+		case redactActionHash:
+			out = append(out, attribute.String(string(kv.Key), r.hash(kv.Value.Emit())))
+		case redactActionMask:
+			out = append(out, attribute.String(string(kv.Key), redactedValue))
+		}
+	}
+	return out
+}
+
+// logFields redacta en el lugar los campos de una entrada de log.
+func (r *redactor) logFields(fields map[string]interface{}) {
+	for key, action := range r.rules {
+		value, ok := fields[key]
+		if !ok {
+			continue
+		}
+
+		redactionsTotal.WithLabelValues("logs", key, action).Inc()
+		switch action {
+		case redactActionDrop:
+			delete(fields, key)
+		case redactActionHash:
+			fields[key] = r.hash(fmt.Sprint(value))
+		default:
+			fields[key] = redactedValue
+		}
+	}
+}
+
+// redactingExporter limpia los atributos de cada span, sus eventos, sus
+// links y su resource antes de exportarlo (o guardarlo en el buffer local).
+type redactingExporter struct {
+	trace.SpanExporter
+	redactor *redactor
+}
+
+func (e redactingExporter) ExportSpans(ctx context.Context, spans []trace.ReadOnlySpan) error {
+	if !e.redactor.enabled() {
+		return e.SpanExporter.ExportSpans(ctx, spans)
+	}
+
+	stubs := tracetest.SpanStubsFromReadOnlySpans(spans)
+	for i := range stubs {
+		stubs[i].Attributes = e.redactor.attributes(stubs[i].Attributes)
+		for j := range stubs[i].Events {
+			stubs[i].Events[j].Attributes = e.redactor.attributes(stubs[i].Events[j].Attributes)
+		}
+		for j := range stubs[i].Links {
+			stubs[i].Links[j].Attributes = e.redactor.attributes(stubs[i].Links[j].Attributes)
+		}
+		if res := stubs[i].Resource; res != nil {
+			stubs[i].Resource = resource.NewWithAttributes(res.SchemaURL(), e.redactor.attributes(res.Attributes())...)
+		}
+	}
+	return e.SpanExporter.ExportSpans(ctx, stubs.Snapshots())
+}
@@ -0,0 +1,234 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/signal"
+	"sort"
+	"strings"
+	"sync/atomic"
+	"syscall"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var configReloads = prometheus.NewCounterVec(
+	prometheus.CounterOpts{
+		Name: "app1_config_reloads_total",
+		Help: "Configuration reloads by trigger (sighup, file) and result (success, error)",
+	},
+	[]string{"trigger", "result"},
+)
+
+func init() {
+	prometheus.MustRegister(configReloads)
+	logLevel.Store(parseLogLevel(getConfig("LOG_LEVEL")))
+}
+
+// Claves que se pueden cambiar sin reiniciar. El resto define listeners,
+// exporters o recursos que se crean una sola vez al arrancar.
+var reloadableKeys = map[string]bool{
+	"LOG_LEVEL":              true,
+	"TRACE_SAMPLING_RATIO":   true,
+	"ACCESS_LOG_SAMPLE":      true,
+	"DEPENDENCY_ERROR_RATES": true,
+	"BOT_SCORE_THRESHOLD":    true,
+	"BOT_RATE_THRESHOLD":     true,
+	"BOT_ACTION":             true,
+	"BOT_TARPIT_DELAY":       true,
+	"CLIENT_DAILY_QUOTA":     true,
+	"CLIENT_QUOTAS":          true,
+	"CLIENT_OVERFLOW_QUOTA":  true,
+}
+
+// Niveles de log en orden de severidad; LOG_LEVEL descarta los menores.
+var logLevelNames = []string{"debug", "info", "warn", "error"}
+
+var logLevel atomic.Int32
+
+func parseLogLevel(value string) int32 {
+	for i, name := range logLevelNames {
+		if strings.EqualFold(value, name) {
+			return int32(i)
+		}
+	}
+	return 1 // info
+}
+
+// logEnabled indica si un mensaje de ese nivel se escribe. Los niveles
+// desconocidos se escriben siempre.
+func logEnabled(level string) bool {
+	for i, name := range logLevelNames {
+		if name == level {
+			return int32(i) >= logLevel.Load()
+		}
+	}
+	return true
+}
+
+// configOverlay son los valores de CONFIG_FILE, que tienen prioridad sobre
+// el entorno para las claves recargables.
+var configOverlay = newConfigOverlay()
+
+func newConfigOverlay() *atomic.Pointer[map[string]string] {
+	overlay := &atomic.Pointer[map[string]string]{}
+	values := map[string]string{}
+	if path := os.Getenv("CONFIG_FILE"); path != "" {
+		if loaded, err := loadConfigFile(path); err == nil {
+			values = loaded
+		} else {
+			logMessage("warn", "Error loading CONFIG_FILE, using environment only: "+err.Error(), "")
+		}
+	}
+	overlay.Store(&values)
+	return overlay
+}
+
+// getConfig devuelve el valor de CONFIG_FILE si la clave está ahí y si no
+// el del entorno.
+func getConfig(key string) string {
+	if v, ok := (*configOverlay.Load())[key]; ok {
+		return v
+	}
+	return os.Getenv(key)
+}
+
+// configSource indica de dónde sale el valor efectivo de una clave.
+func configSource(key string) string {
+	if _, ok := (*configOverlay.Load())[key]; ok {
+		return "file"
+	}
+	if _, ok := os.LookupEnv(key); ok {
+		return "env"
+	}
+	return "default"
+}
+
+// loadConfigFile lee líneas CLAVE=valor; las vacías y # se ignoran. Las
+// claves que requieren reinicio se descartan con un aviso.
+func loadConfigFile(path string) (map[string]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	values := make(map[string]string)
+	scanner := bufio.NewScanner(f)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		key, value, ok := strings.Cut(text, "=")
+		if !ok {
+			return nil, fmt.Errorf("line %d: expected KEY=value", line)
+		}
+		key = strings.TrimSpace(key)
+		if !reloadableKeys[key] {
+			logMessage("warn", "CONFIG_FILE key "+key+" requires a restart, ignoring", "")
+			continue
+		}
+		values[key] = strings.Trim(strings.TrimSpace(value), `"`)
+	}
+	return values, scanner.Err()
+}
+
+// reloadConfig vuelve a leer CONFIG_FILE, aplica las claves recargables y
+// loguea la diferencia de la configuración resuelta (con los secretos ya
+// enmascarados por resolveConfig).
+func reloadConfig(trigger string) {
+	before := resolveConfig()
+	previousRatio := configuredSamplingRatio()
+
+	if path := os.Getenv("CONFIG_FILE"); path != "" {
+		values, err := loadConfigFile(path)
+		if err != nil {
+			configReloads.WithLabelValues(trigger, "error").Inc()
+			logMessage("error", "Config reload failed, keeping current configuration: "+err.Error(), "")
+			return
+		}
+		configOverlay.Store(&values)
+	}
+
+	logLevel.Store(parseLogLevel(getConfig("LOG_LEVEL")))
+	// Solo si cambió el ratio configurado: si no, se respeta el que se haya
+	// fijado en caliente con /admin/tracing/sampling
+	if ratio := configuredSamplingRatio(); ratio != previousRatio {
+		traceSampler.SetRatio(ratio)
+	}
+	accessLog.reloadSample()
+	dependencyErrorRates.reload()
+	bots.settings.Store(loadBotSettings())
+	meter.settings.Store(loadQuotaSettings())
+
+	after := resolveConfig()
+	changes := map[string]interface{}{}
+	for key, value := range after.Config {
+		old, _ := json.Marshal(before.Config[key].Value)
+		current, _ := json.Marshal(value.Value)
+		if string(old) != string(current) {
+			changes[key] = map[string]interface{}{"old": before.Config[key].Value, "new": value.Value}
+		}
+	}
+
+	keys := make([]string, 0, len(changes))
+	for key := range changes {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	// Se escribe siempre, aunque el nuevo LOG_LEVEL descarte los info
+	configReloads.WithLabelValues(trigger, "success").Inc()
+	writeLog("info", fmt.Sprintf("Configuration reloaded via %s: %d changes", trigger, len(changes)), "", map[string]interface{}{
+		"config_trigger":      trigger,
+		"config_changed_keys": keys,
+		"config_changes":      changes,
+		"config_hash":         after.Hash,
+	})
+}
+
+// Intervalo de chequeo de CONFIG_FILE. Se compara por polling con os.Stat,
+// que sigue symlinks: así se detecta el swap atómico de un ConfigMap montado.
+const configWatchInterval = 5 * time.Second
+
+// watchConfig recarga la configuración al recibir SIGHUP o al cambiar
+// CONFIG_FILE.
+func watchConfig(ctx context.Context) {
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	defer signal.Stop(hup)
+
+	path := os.Getenv("CONFIG_FILE")
+	var lastMod time.Time
+	var lastSize int64
+	if info, err := os.Stat(path); path != "" && err == nil {
+		lastMod, lastSize = info.ModTime(), info.Size()
+	}
+
+	ticker := time.NewTicker(configWatchInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-hup:
+			reloadConfig("sighup")
+		case <-ticker.C:
+			if path == "" {
+				continue
+			}
+			info, err := os.Stat(path)
+			if err != nil || (info.ModTime().Equal(lastMod) && info.Size() == lastSize) {
+				continue
+			}
+			lastMod, lastSize = info.ModTime(), info.Size()
+			reloadConfig("file")
+		}
+	}
+}
@@ -0,0 +1,166 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync/atomic"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/sdk/trace"
+	oteltrace "go.opentelemetry.io/otel/trace"
+)
+
+// Atributo que otelhttp agrega al iniciar el span del servidor
+const httpTargetKey = attribute.Key("http.target")
+
+var (
+	samplingRatio = prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "app1_tracing_sampling_ratio",
+			Help: "Current trace sampling ratio for root spans",
+		},
+	)
+
+	suppressedSpans = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "app1_tracing_suppressed_spans_total",
+			Help: "Root spans dropped for routes configured in TRACE_SUPPRESS_ROUTES",
+		},
+		[]string{"route"},
+	)
+)
+
+var traceSampler = newDynamicSampler()
+
+func init() {
+	prometheus.MustRegister(samplingRatio)
+	prometheus.MustRegister(suppressedSpans)
+	describeMetric("app1_tracing_sampling_ratio", metricMeta{Panel: "stat", Unit: "percentunit"})
+}
+
+// dynamicSampler permite cambiar el ratio de muestreo en caliente. Respeta
+// la decisión del padre para no cortar trazas distribuidas. Las rutas
+// ruidosas (health checks, scrapes) usan su propio ratio, 0 por defecto.
+type dynamicSampler struct {
+	ratio   atomic.Uint64
+	sampler atomic.Value
+
+	suppressedRoutes  map[string]bool
+	suppressedSampler trace.Sampler
+}
+
+func newDynamicSampler() *dynamicSampler {
+	routes := "/health,/metrics"
+	if v, ok := os.LookupEnv("TRACE_SUPPRESS_ROUTES"); ok {
+		routes = v
+	}
+
+	suppressRatio := 0.0
+	if v := os.Getenv("TRACE_SUPPRESS_RATIO"); v != "" {
+		if parsed, err := strconv.ParseFloat(v, 64); err == nil && parsed >= 0 && parsed <= 1 {
+			suppressRatio = parsed
+		}
+	}
+
+	s := &dynamicSampler{
+		suppressedRoutes:  map[string]bool{},
+		suppressedSampler: trace.TraceIDRatioBased(suppressRatio),
+	}
+	for _, route := range strings.Split(routes, ",") {
+		if route = strings.TrimSpace(route); route != "" {
+			s.suppressedRoutes[route] = true
+		}
+	}
+	s.SetRatio(configuredSamplingRatio())
+	return s
+}
+
+// configuredSamplingRatio es el ratio de TRACE_SAMPLING_RATIO. Una recarga
+// de configuración conserva el fijado por la API salvo que haya cambiado
+// TRACE_SAMPLING_RATIO.
+func configuredSamplingRatio() float64 {
+	if v := getConfig("TRACE_SAMPLING_RATIO"); v != "" {
+		if parsed, err := strconv.ParseFloat(v, 64); err == nil && parsed >= 0 && parsed <= 1 {
+			return parsed
+		}
+	}
+	return 1
+}
+
+func (s *dynamicSampler) Ratio() float64 {
+	return math.Float64frombits(s.ratio.Load())
+}
+
+func (s *dynamicSampler) SetRatio(ratio float64) {
+	s.ratio.Store(math.Float64bits(ratio))
+	s.sampler.Store(trace.ParentBased(trace.TraceIDRatioBased(ratio)))
+	samplingRatio.Set(ratio)
+}
+
+func (s *dynamicSampler) ShouldSample(p trace.SamplingParameters) trace.SamplingResult {
+	if route := s.suppressedRoute(p); route != "" {
+		result := s.suppressedSampler.ShouldSample(p)
+		if result.Decision == trace.Drop {
+			suppressedSpans.WithLabelValues(route).Inc()
+		}
+		return result
+	}
+	return s.sampler.Load().(trace.Sampler).ShouldSample(p)
+}
+
+// suppressedRoute devuelve la ruta si el span raíz corresponde a una ruta
+// suprimida. Los spans con padre siguen la decisión de la traza.
+func (s *dynamicSampler) suppressedRoute(p trace.SamplingParameters) string {
+	if len(s.suppressedRoutes) == 0 || oteltrace.SpanContextFromContext(p.ParentContext).IsValid() {
+		return ""
+	}
+	for _, attr := range p.Attributes {
+		if attr.Key == httpTargetKey && s.suppressedRoutes[attr.Value.AsString()] {
+			return attr.Value.AsString()
+		}
+	}
+	return ""
+}
+
+func (s *dynamicSampler) Description() string {
+	return fmt.Sprintf("DynamicSampler{%g}", s.Ratio())
+}
+
+type samplingConfig struct {
+	Ratio float64 `json:"ratio"`
+}
+
+// samplingHandler expone y modifica el ratio de muestreo:
+// GET devuelve el valor actual, PUT/POST recibe {"ratio": 0.25}.
+func samplingHandler(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+	case http.MethodPut, http.MethodPost:
+		var config samplingConfig
+		if err := json.NewDecoder(r.Body).Decode(&config); err != nil {
+			http.Error(w, "invalid JSON body", http.StatusBadRequest)
+			return
+		}
+		if config.Ratio < 0 || config.Ratio > 1 {
+			http.Error(w, "ratio must be between 0 and 1", http.StatusBadRequest)
+			return
+		}
+
+		previous := traceSampler.Ratio()
+		traceSampler.SetRatio(config.Ratio)
+		logMessage("info", fmt.Sprintf("Trace sampling ratio changed from %g to %g", previous, config.Ratio), "")
+	default:
+		w.Header().Set("Allow", "GET, PUT, POST")
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(samplingConfig{Ratio: traceSampler.Ratio()})
+}
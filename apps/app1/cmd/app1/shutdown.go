@@ -0,0 +1,45 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"time"
+
+	"go.opentelemetry.io/otel/sdk/trace"
+)
+
+// shutdownTimeout acota el drenado de requests y el flush de spans. Por
+// defecto queda por debajo de los 30s de terminationGracePeriodSeconds.
+func shutdownTimeout() time.Duration {
+	if v, err := time.ParseDuration(os.Getenv("SHUTDOWN_TIMEOUT")); err == nil && v > 0 {
+		return v
+	}
+	return 25 * time.Second
+}
+
+// gracefulShutdown deja de aceptar conexiones (TCP y unix), espera a que
+// terminen las requests en curso y después exporta los spans pendientes,
+// para que Tempo reciba también las trazas de esas últimas requests.
+func gracefulShutdown(server *http.Server, tp *trace.TracerProvider) {
+	start := time.Now()
+	ctx, cancel := context.WithTimeout(context.Background(), shutdownTimeout())
+	defer cancel()
+
+	logMessage("info", fmt.Sprintf("Shutting down, draining %d in-flight requests", inFlight.Load()), "")
+
+	if err := server.Shutdown(ctx); err != nil {
+		logMessage("warn", "HTTP server did not drain in time: "+err.Error(), "")
+	}
+	// El reenvío del buffer se corta antes del flush final: lo que quede en
+	// disco se reenvía en el próximo arranque
+	spanBuffer.stop()
+	if err := tp.Shutdown(ctx); err != nil {
+		logMessage("warn", "Error flushing tracer provider: "+err.Error(), "")
+	}
+
+	logWithFields("info", "Shutdown complete", "", map[string]interface{}{
+		"shutdown_duration_ms": time.Since(start).Milliseconds(),
+	})
+}
@@ -0,0 +1,102 @@
+package main
+
+import (
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Eventos de SLI ya agregados por ruta, para armar SLOs por ruta con un
+// simple cociente good/total sin recording rules:
+//
+//	sum(rate(app1_route_sli_good_total{sli="latency"}[30d])) by (route)
+//	  / sum(rate(app1_route_sli_events_total{sli="latency"}[30d])) by (route)
+var (
+	sliEvents = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "app1_route_sli_events_total",
+			Help: "Requests counted towards each route SLI (availability, latency)",
+		},
+		[]string{"route", "sli"},
+	)
+
+	sliGood = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "app1_route_sli_good_total",
+			Help: "Requests that met the route SLI: non-5xx for availability, under the route threshold for latency",
+		},
+		[]string{"route", "sli"},
+	)
+)
+
+func init() {
+	prometheus.MustRegister(sliEvents)
+	prometheus.MustRegister(sliGood)
+}
+
+// Umbral de latencia para las rutas sin uno propio en SLI_LATENCY_THRESHOLDS
+const defaultSLILatencyThreshold = 500 * time.Millisecond
+
+var sliLatencyThresholds = loadSLILatencyThresholds()
+
+// Probes y scrapes no son tráfico de usuarios
+var sliExemptPaths = map[string]bool{"/metrics": true, "/health": true}
+
+// loadSLILatencyThresholds lee SLI_LATENCY_THRESHOLDS="ruta=duración,...".
+func loadSLILatencyThresholds() map[string]time.Duration {
+	thresholds := map[string]time.Duration{"/slow": 5 * time.Second}
+
+	value := os.Getenv("SLI_LATENCY_THRESHOLDS")
+	if value == "" {
+		return thresholds
+	}
+	for _, entry := range strings.Split(value, ",") {
+		route, duration, _ := strings.Cut(strings.TrimSpace(entry), "=")
+		d, err := time.ParseDuration(duration)
+		if err != nil || d <= 0 {
+			logMessage("warn", "Invalid SLI_LATENCY_THRESHOLDS entry "+entry+", ignoring", "")
+			continue
+		}
+		thresholds[route] = d
+	}
+	return thresholds
+}
+
+func sliLatencyThreshold(route string) time.Duration {
+	if d, ok := sliLatencyThresholds[route]; ok {
+		return d
+	}
+	return defaultSLILatencyThreshold
+}
+
+// sliMiddleware cuenta eventos buenos y totales por patrón de ruta. La
+// latencia solo se evalúa sobre respuestas exitosas, para que un error
+// rápido no cuente como bueno en las dos SLIs.
+func sliMiddleware(mux *http.ServeMux, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if sliExemptPaths[r.URL.Path] {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		start := time.Now()
+		rec := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+
+		next.ServeHTTP(rec, r)
+
+		route := routeTemplate(mux, r)
+		sliEvents.WithLabelValues(route, "availability").Inc()
+		if rec.status >= 500 {
+			return
+		}
+		sliGood.WithLabelValues(route, "availability").Inc()
+
+		sliEvents.WithLabelValues(route, "latency").Inc()
+		if time.Since(start) <= sliLatencyThreshold(route) {
+			sliGood.WithLabelValues(route, "latency").Inc()
+		}
+	})
+}
@@ -0,0 +1,439 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/sdk/instrumentation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	"go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	oteltrace "go.opentelemetry.io/otel/trace"
+)
+
+var (
+	spansBuffered = prometheus.NewCounter(
+		prometheus.CounterOpts{
+			Name: "app1_telemetry_spans_buffered_total",
+			Help: "Spans written to the local disk buffer after a failed export",
+		},
+	)
+
+	spansReplayed = prometheus.NewCounter(
+		prometheus.CounterOpts{
+			Name: "app1_telemetry_spans_replayed_total",
+			Help: "Buffered spans successfully re-exported after the OTLP endpoint recovered",
+		},
+	)
+
+	spanBufferBytes = prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "app1_telemetry_span_buffer_bytes",
+			Help: "Bytes currently held in the local span buffer",
+		},
+	)
+)
+
+func init() {
+	prometheus.MustRegister(spansBuffered)
+	prometheus.MustRegister(spansReplayed)
+	prometheus.MustRegister(spanBufferBytes)
+	describeMetric("app1_telemetry_span_buffer_bytes", metricMeta{Unit: "bytes"})
+}
+
+// spanBuffer es el buffer del tracer provider; nil si SPAN_BUFFER_MAX_MB=0
+var spanBuffer *spanSpool
+
+// spanSpool guarda en disco los lotes de spans que no se pudieron exportar,
+// hasta maxBytes, para reenviarlos cuando el endpoint OTLP vuelva.
+type spanSpool struct {
+	dir      string
+	maxBytes int64
+
+	mu   sync.Mutex
+	size int64
+
+	// El worker de reenvío se despierta con notify y se detiene con stop
+	trigger chan struct{}
+	cancel  context.CancelFunc
+	done    chan struct{}
+}
+
+func newSpanSpool() (*spanSpool, error) {
+	dir := os.Getenv("SPAN_BUFFER_DIR")
+	if dir == "" {
+		dir = filepath.Join(os.TempDir(), "app1-spans")
+	}
+
+	maxMB := int64(16)
+	if v := os.Getenv("SPAN_BUFFER_MAX_MB"); v != "" {
+		parsed, err := strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid SPAN_BUFFER_MAX_MB %q: %w", v, err)
+		}
+		maxMB = parsed
+	}
+	if maxMB <= 0 {
+		return nil, nil
+	}
+
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+
+	s := &spanSpool{dir: dir, maxBytes: maxMB << 20, trigger: make(chan struct{}, 1)}
+
+	// Lotes que quedaron de una ejecución anterior
+	files, err := s.files()
+	if err != nil {
+		return nil, err
+	}
+	for _, f := range files {
+		if info, err := os.Stat(f); err == nil {
+			s.size += info.Size()
+		}
+	}
+	spanBufferBytes.Set(float64(s.size))
+
+	return s, nil
+}
+
+func (s *spanSpool) files() ([]string, error) {
+	files, err := filepath.Glob(filepath.Join(s.dir, "*.json"))
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(files)
+	return files, nil
+}
+
+// write persiste un lote; devuelve error si el buffer está lleno.
+func (s *spanSpool) write(spans []trace.ReadOnlySpan) error {
+	batch := make([]spooledSpan, 0, len(spans))
+	for _, span := range spans {
+		batch = append(batch, newSpooledSpan(tracetest.SpanStubFromReadOnlySpan(span)))
+	}
+
+	data, err := json.Marshal(batch)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.size+int64(len(data)) > s.maxBytes {
+		return fmt.Errorf("span buffer full (%d bytes)", s.size)
+	}
+
+	name := filepath.Join(s.dir, fmt.Sprintf("%020d.json", time.Now().UnixNano()))
+	if err := os.WriteFile(name, data, 0o644); err != nil {
+		return err
+	}
+
+	s.size += int64(len(data))
+	spanBufferBytes.Set(float64(s.size))
+	return nil
+}
+
+// start lanza el único worker de reenvío. Su contexto se cancela en stop,
+// así ninguna exportación sigue corriendo después del apagado.
+func (s *spanSpool) start(export func(context.Context, []trace.ReadOnlySpan) error) {
+	ctx, cancel := context.WithCancel(context.Background())
+	s.cancel = cancel
+	s.done = make(chan struct{})
+
+	go func() {
+		defer close(s.done)
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-s.trigger:
+				s.replay(ctx, export)
+			}
+		}
+	}()
+}
+
+// notify pide un reenvío; si ya hay uno pedido no hace nada.
+func (s *spanSpool) notify() {
+	select {
+	case s.trigger <- struct{}{}:
+	default:
+	}
+}
+
+// stop cancela el reenvío en curso y espera a que el worker termine.
+func (s *spanSpool) stop() {
+	if s == nil || s.cancel == nil {
+		return
+	}
+	s.cancel()
+	<-s.done
+}
+
+// replay reenvía los lotes guardados en orden, deteniéndose en el primer
+// fallo o cuando se cancela ctx.
+func (s *spanSpool) replay(ctx context.Context, export func(context.Context, []trace.ReadOnlySpan) error) {
+	s.mu.Lock()
+	empty := s.size == 0
+	s.mu.Unlock()
+	if empty {
+		return
+	}
+
+	files, err := s.files()
+	if err != nil {
+		return
+	}
+
+	for _, name := range files {
+		if ctx.Err() != nil {
+			return
+		}
+
+		var size int64
+		if info, err := os.Stat(name); err == nil {
+			size = info.Size()
+		}
+
+		data, err := os.ReadFile(name)
+		if err != nil {
+			logMessage("warn", "Discarding unreadable span buffer file "+name+": "+err.Error(), "")
+			s.remove(name, size)
+			continue
+		}
+
+		var batch []spooledSpan
+		if err := json.Unmarshal(data, &batch); err != nil {
+			logMessage("warn", "Discarding corrupt span buffer file "+name, "")
+		} else {
+			stubs := make(tracetest.SpanStubs, 0, len(batch))
+			for _, b := range batch {
+				stubs = append(stubs, b.stub())
+			}
+			if err := export(ctx, stubs.Snapshots()); err != nil {
+				return
+			}
+			spansReplayed.Add(float64(len(batch)))
+		}
+
+		s.remove(name, int64(len(data)))
+	}
+}
+
+// remove borra un lote y lo descuenta del tamaño del buffer.
+func (s *spanSpool) remove(name string, size int64) {
+	if err := os.Remove(name); err != nil && !os.IsNotExist(err) {
+		logMessage("warn", "Could not remove span buffer file "+name+": "+err.Error(), "")
+		return
+	}
+
+	s.mu.Lock()
+	s.size -= size
+	if s.size < 0 {
+		s.size = 0
+	}
+	spanBufferBytes.Set(float64(s.size))
+	s.mu.Unlock()
+}
+
+// Representación en disco de un span. Los atributos guardan su tipo para
+// reconstruirlos sin perder precisión.
+type spooledSpan struct {
+	Name              string         `json:"name"`
+	TraceID           string         `json:"trace_id"`
+	SpanID            string         `json:"span_id"`
+	ParentSpanID      string         `json:"parent_span_id,omitempty"`
+	TraceFlags        byte           `json:"trace_flags"`
+	Kind              int            `json:"kind"`
+	Start             time.Time      `json:"start"`
+	End               time.Time      `json:"end"`
+	Attributes        []spooledAttr  `json:"attributes,omitempty"`
+	Events            []spooledEvent `json:"events,omitempty"`
+	Links             []spooledLink  `json:"links,omitempty"`
+	StatusCode        uint32         `json:"status_code"`
+	StatusDescription string         `json:"status_description,omitempty"`
+	ChildSpanCount    int            `json:"child_span_count"`
+	Resource          []spooledAttr  `json:"resource,omitempty"`
+	ResourceSchemaURL string         `json:"resource_schema_url,omitempty"`
+	Scope             spooledScope   `json:"scope"`
+}
+
+type spooledEvent struct {
+	Name       string        `json:"name"`
+	Time       time.Time     `json:"time"`
+	Attributes []spooledAttr `json:"attributes,omitempty"`
+}
+
+type spooledLink struct {
+	TraceID    string        `json:"trace_id"`
+	SpanID     string        `json:"span_id"`
+	Attributes []spooledAttr `json:"attributes,omitempty"`
+}
+
+type spooledScope struct {
+	Name      string `json:"name"`
+	Version   string `json:"version,omitempty"`
+	SchemaURL string `json:"schema_url,omitempty"`
+}
+
+type spooledAttr struct {
+	Key     string    `json:"key"`
+	Type    string    `json:"type"`
+	Bool    bool      `json:"bool,omitempty"`
+	Int     int64     `json:"int,omitempty"`
+	Float   float64   `json:"float,omitempty"`
+	String  string    `json:"string,omitempty"`
+	Bools   []bool    `json:"bools,omitempty"`
+	Ints    []int64   `json:"ints,omitempty"`
+	Floats  []float64 `json:"floats,omitempty"`
+	Strings []string  `json:"strings,omitempty"`
+}
+
+func newSpooledSpan(stub tracetest.SpanStub) spooledSpan {
+	s := spooledSpan{
+		Name:              stub.Name,
+		TraceID:           stub.SpanContext.TraceID().String(),
+		SpanID:            stub.SpanContext.SpanID().String(),
+		TraceFlags:        byte(stub.SpanContext.TraceFlags()),
+		Kind:              int(stub.SpanKind),
+		Start:             stub.StartTime,
+		End:               stub.EndTime,
+		Attributes:        newSpooledAttrs(stub.Attributes),
+		StatusCode:        uint32(stub.Status.Code),
+		StatusDescription: stub.Status.Description,
+		ChildSpanCount:    stub.ChildSpanCount,
+		Scope: spooledScope{
+			Name:      stub.InstrumentationLibrary.Name,
+			Version:   stub.InstrumentationLibrary.Version,
+			SchemaURL: stub.InstrumentationLibrary.SchemaURL,
+		},
+	}
+
+	if stub.Parent.HasSpanID() {
+		s.ParentSpanID = stub.Parent.SpanID().String()
+	}
+	if stub.Resource != nil {
+		s.Resource = newSpooledAttrs(stub.Resource.Attributes())
+		s.ResourceSchemaURL = stub.Resource.SchemaURL()
+	}
+	for _, e := range stub.Events {
+		s.Events = append(s.Events, spooledEvent{Name: e.Name, Time: e.Time, Attributes: newSpooledAttrs(e.Attributes)})
+	}
+	for _, l := range stub.Links {
+		s.Links = append(s.Links, spooledLink{
+			TraceID:    l.SpanContext.TraceID().String(),
+			SpanID:     l.SpanContext.SpanID().String(),
+			Attributes: newSpooledAttrs(l.Attributes),
+		})
+	}
+
+	return s
+}
+
+func (s spooledSpan) stub() tracetest.SpanStub {
+	traceID, _ := oteltrace.TraceIDFromHex(s.TraceID)
+	spanID, _ := oteltrace.SpanIDFromHex(s.SpanID)
+
+	stub := tracetest.SpanStub{
+		Name: s.Name,
+		SpanContext: oteltrace.NewSpanContext(oteltrace.SpanContextConfig{
+			TraceID:    traceID,
+			SpanID:     spanID,
+			TraceFlags: oteltrace.TraceFlags(s.TraceFlags),
+		}),
+		SpanKind:       oteltrace.SpanKind(s.Kind),
+		StartTime:      s.Start,
+		EndTime:        s.End,
+		Attributes:     spooledAttrsToKeyValues(s.Attributes),
+		Status:         trace.Status{Code: codes.Code(s.StatusCode), Description: s.StatusDescription},
+		ChildSpanCount: s.ChildSpanCount,
+		Resource:       resource.NewWithAttributes(s.ResourceSchemaURL, spooledAttrsToKeyValues(s.Resource)...),
+		InstrumentationLibrary: instrumentation.Library{
+			Name:      s.Scope.Name,
+			Version:   s.Scope.Version,
+			SchemaURL: s.Scope.SchemaURL,
+		},
+	}
+
+	if parentID, err := oteltrace.SpanIDFromHex(s.ParentSpanID); err == nil {
+		stub.Parent = oteltrace.NewSpanContext(oteltrace.SpanContextConfig{TraceID: traceID, SpanID: parentID})
+	}
+	for _, e := range s.Events {
+		stub.Events = append(stub.Events, trace.Event{Name: e.Name, Time: e.Time, Attributes: spooledAttrsToKeyValues(e.Attributes)})
+	}
+	for _, l := range s.Links {
+		linkTraceID, _ := oteltrace.TraceIDFromHex(l.TraceID)
+		linkSpanID, _ := oteltrace.SpanIDFromHex(l.SpanID)
+		stub.Links = append(stub.Links, trace.Link{
+			SpanContext: oteltrace.NewSpanContext(oteltrace.SpanContextConfig{TraceID: linkTraceID, SpanID: linkSpanID}),
+			Attributes:  spooledAttrsToKeyValues(l.Attributes),
+		})
+	}
+
+	return stub
+}
+
+func newSpooledAttrs(kvs []attribute.KeyValue) []spooledAttr {
+	attrs := make([]spooledAttr, 0, len(kvs))
+	for _, kv := range kvs {
+		a := spooledAttr{Key: string(kv.Key), Type: kv.Value.Type().String()}
+		switch kv.Value.Type() {
+		case attribute.BOOL:
+			a.Bool = kv.Value.AsBool()
+		case attribute.INT64:
+			a.Int = kv.Value.AsInt64()
+		case attribute.FLOAT64:
+			a.Float = kv.Value.AsFloat64()
+		case attribute.BOOLSLICE:
+			a.Bools = kv.Value.AsBoolSlice()
+		case attribute.INT64SLICE:
+			a.Ints = kv.Value.AsInt64Slice()
+		case attribute.FLOAT64SLICE:
+			a.Floats = kv.Value.AsFloat64Slice()
+		case attribute.STRINGSLICE:
+			a.Strings = kv.Value.AsStringSlice()
+		default:
+			a.String = kv.Value.Emit()
+		}
+		attrs = append(attrs, a)
+	}
+	return attrs
+}
+
+func spooledAttrsToKeyValues(attrs []spooledAttr) []attribute.KeyValue {
+	kvs := make([]attribute.KeyValue, 0, len(attrs))
+	for _, a := range attrs {
+		switch a.Type {
+		case attribute.BOOL.String():
+			kvs = append(kvs, attribute.Bool(a.Key, a.Bool))
+		case attribute.INT64.String():
+			kvs = append(kvs, attribute.Int64(a.Key, a.Int))
+		case attribute.FLOAT64.String():
+			kvs = append(kvs, attribute.Float64(a.Key, a.Float))
+		case attribute.BOOLSLICE.String():
+			kvs = append(kvs, attribute.BoolSlice(a.Key, a.Bools))
+		case attribute.INT64SLICE.String():
+			kvs = append(kvs, attribute.Int64Slice(a.Key, a.Ints))
+		case attribute.FLOAT64SLICE.String():
+			kvs = append(kvs, attribute.Float64Slice(a.Key, a.Floats))
+		case attribute.STRINGSLICE.String():
+			kvs = append(kvs, attribute.StringSlice(a.Key, a.Strings))
+		default:
+			kvs = append(kvs, attribute.String(a.Key, a.String))
+		}
+	}
+	return kvs
+}
@@ -0,0 +1,243 @@
+package main
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	oteltrace "go.opentelemetry.io/otel/trace"
+)
+
+// Instante de arranque del proceso, antes de inicializar cualquier componente
+var processStart = time.Now()
+
+// Versión desplegada, para comparar arranques entre releases
+var serviceVersion = func() string {
+	if v := os.Getenv("SERVICE_VERSION"); v != "" {
+		return v
+	}
+	return "1.0.0"
+}()
+
+var (
+	startupPhaseDuration = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "app1_startup_phase_duration_seconds",
+			Help: "Duration of each startup phase (tracing, warmup, listen, total)",
+		},
+		[]string{"phase", "version"},
+	)
+
+	dependencyWarmup = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "app1_startup_dependency_warmup_seconds",
+			Help: "Time to open the first connection to each dependency at startup",
+		},
+		[]string{"dependency", "result", "version"},
+	)
+
+	timeToFirstSuccess = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "app1_startup_time_to_first_success_seconds",
+			Help: "Time from process start until the first successful (non 5xx) response",
+		},
+		[]string{"version"},
+	)
+
+	warmupRequestDuration = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name:    "app1_warmup_request_duration_seconds",
+			Help:    "Request latency split between the first COLD_START_REQUESTS requests (cold) and the rest (warm)",
+			Buckets: dependencyBuckets,
+		},
+		[]string{"period", "version"},
+	)
+)
+
+func init() {
+	prometheus.MustRegister(startupPhaseDuration)
+	prometheus.MustRegister(dependencyWarmup)
+	prometheus.MustRegister(timeToFirstSuccess)
+	prometheus.MustRegister(warmupRequestDuration)
+}
+
+type startupPhase struct {
+	name       string
+	start, end time.Time
+	attrs      []attribute.KeyValue
+}
+
+// startupRecorder junta las fases del arranque para exponerlas como
+// métricas y, cuando el tracer ya existe, como un árbol de spans.
+type startupRecorder struct {
+	mu     sync.Mutex
+	phases []startupPhase
+
+	coldRequests int64
+	served       atomic.Int64
+	firstSuccess sync.Once
+}
+
+var startup = newStartupRecorder()
+
+func newStartupRecorder() *startupRecorder {
+	r := &startupRecorder{coldRequests: 100}
+	if v, err := strconv.ParseInt(os.Getenv("COLD_START_REQUESTS"), 10, 64); err == nil && v >= 0 {
+		r.coldRequests = v
+	}
+	return r
+}
+
+// phase registra una fase principal del arranque (métrica y span).
+func (r *startupRecorder) phase(name string, start time.Time) {
+	startupPhaseDuration.WithLabelValues(name, serviceVersion).Set(time.Since(start).Seconds())
+	r.record(name, start)
+}
+
+// record guarda un tramo que solo se emite como span.
+func (r *startupRecorder) record(name string, start time.Time, attrs ...attribute.KeyValue) {
+	r.mu.Lock()
+	r.phases = append(r.phases, startupPhase{name: name, start: start, end: time.Now(), attrs: attrs})
+	r.mu.Unlock()
+}
+
+// warmupDependencies abre una conexión a cada dependencia en paralelo para
+// que DNS y handshake no se paguen en la primera request real.
+func (r *startupRecorder) warmupDependencies() {
+	targets := map[string]string{"tempo": os.Getenv("TEMPO_ENDPOINT")}
+	if targets["tempo"] == "" {
+		targets["tempo"] = "http://tempo:4318"
+	}
+	if scorer.enabled() {
+		targets["prometheus"] = scorer.prometheusURL
+	}
+
+	start := time.Now()
+	var wg sync.WaitGroup
+	for name, endpoint := range targets {
+		wg.Add(1)
+		go func(name, endpoint string) {
+			defer wg.Done()
+
+			dialStart := time.Now()
+			err := dialDependency(endpoint)
+			result := "success"
+			if err != nil {
+				result = "error"
+			}
+			dependencyWarmup.WithLabelValues(name, result, serviceVersion).Set(time.Since(dialStart).Seconds())
+			r.record("warmup "+name, dialStart, attribute.String("warmup.result", result))
+		}(name, endpoint)
+	}
+	wg.Wait()
+
+	r.phase("warmup", start)
+}
+
+func dialDependency(endpoint string) error {
+	host := endpoint
+	if u, err := url.Parse(endpoint); err == nil && u.Host != "" {
+		port := u.Port()
+		if port == "" {
+			port = "80"
+			if u.Scheme == "https" {
+				port = "443"
+			}
+		}
+		host = net.JoinHostPort(u.Hostname(), port)
+	}
+
+	conn, err := net.DialTimeout("tcp", host, 2*time.Second)
+	if err != nil {
+		return err
+	}
+	return conn.Close()
+}
+
+// emitSpans reconstruye el arranque como un span "startup" con una fase
+// hija por paso, usando los timestamps registrados.
+func (r *startupRecorder) emitSpans() {
+	end := time.Now()
+	startupPhaseDuration.WithLabelValues("total", serviceVersion).Set(end.Sub(processStart).Seconds())
+
+	tracer := otel.Tracer("app1")
+	ctx, root := tracer.Start(context.Background(), "startup",
+		oteltrace.WithTimestamp(processStart),
+		oteltrace.WithAttributes(attribute.String("service.version", serviceVersion)),
+	)
+
+	r.mu.Lock()
+	phases := r.phases
+	r.mu.Unlock()
+
+	warmCtx := ctx
+	for _, p := range phases {
+		if p.name == "warmup" {
+			var span oteltrace.Span
+			warmCtx, span = tracer.Start(ctx, p.name, oteltrace.WithTimestamp(p.start))
+			span.End(oteltrace.WithTimestamp(p.end))
+		}
+	}
+
+	for _, p := range phases {
+		if p.name == "warmup" {
+			continue
+		}
+		parent := ctx
+		if strings.HasPrefix(p.name, "warmup ") {
+			parent = warmCtx
+		}
+		_, span := tracer.Start(parent, p.name, oteltrace.WithTimestamp(p.start), oteltrace.WithAttributes(p.attrs...))
+		span.End(oteltrace.WithTimestamp(p.end))
+	}
+
+	root.End(oteltrace.WithTimestamp(end))
+}
+
+// Probes y scrapes llegan antes que los usuarios: si contaran, el tiempo a
+// la primera respuesta exitosa sería el tiempo al primer probe
+var startupExemptPaths = map[string]bool{"/metrics": true, "/health": true}
+
+// startupMiddleware mide el tiempo hasta la primera respuesta exitosa y
+// separa la latencia de las primeras requests (frías) del resto.
+func startupMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if startupExemptPaths[r.URL.Path] {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		start := time.Now()
+		rec := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+
+		next.ServeHTTP(rec, r)
+
+		period := "warm"
+		if startup.served.Add(1) <= startup.coldRequests {
+			period = "cold"
+		}
+		warmupRequestDuration.WithLabelValues(period, serviceVersion).Observe(time.Since(start).Seconds())
+
+		if rec.status < 500 {
+			startup.firstSuccess.Do(func() {
+				elapsed := time.Since(processStart)
+				timeToFirstSuccess.WithLabelValues(serviceVersion).Set(elapsed.Seconds())
+				logWithFields("info", "First successful request served", "", map[string]interface{}{
+					"startup_seconds": elapsed.Seconds(),
+					"version":         serviceVersion,
+					"path":            r.URL.Path,
+				})
+			})
+		}
+	})
+}
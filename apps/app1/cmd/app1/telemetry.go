@@ -0,0 +1,152 @@
+package main
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/sdk/trace"
+)
+
+// Métricas del propio pipeline de telemetría ("observability of observability")
+var (
+	spansExported = prometheus.NewCounter(
+		prometheus.CounterOpts{
+			Name: "app1_telemetry_spans_exported_total",
+			Help: "Spans successfully exported to the OTLP endpoint",
+		},
+	)
+
+	spansDropped = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "app1_telemetry_spans_dropped_total",
+			Help: "Spans lost by reason: queue_full (batch queue at capacity) or export_failed (export failed and the spans could not be buffered)",
+		},
+		[]string{"reason"},
+	)
+
+	exportDuration = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name:    "app1_telemetry_export_duration_seconds",
+			Help:    "Duration of span batch exports",
+			Buckets: prometheus.DefBuckets,
+		},
+		[]string{"result"},
+	)
+
+	telemetryErrors = prometheus.NewCounter(
+		prometheus.CounterOpts{
+			Name: "app1_telemetry_errors_total",
+			Help: "Errors reported by the OpenTelemetry SDK",
+		},
+	)
+
+	logFailures = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "app1_telemetry_log_failures_total",
+			Help: "Log entries that could not be encoded or written",
+		},
+		[]string{"reason"},
+	)
+)
+
+// Spans terminados que todavía no se exportaron ni se descartaron
+var spansPending atomic.Int64
+
+// Capacidad de la cola del batcher (el default del SDK)
+const spanQueueSize = 2048
+
+func init() {
+	prometheus.MustRegister(spansExported)
+	prometheus.MustRegister(spansDropped)
+	prometheus.MustRegister(exportDuration)
+	prometheus.MustRegister(telemetryErrors)
+	prometheus.MustRegister(logFailures)
+	prometheus.MustRegister(prometheus.NewGaugeFunc(
+		prometheus.GaugeOpts{
+			Name: "app1_telemetry_span_queue_size",
+			Help: "Spans ended but not yet exported",
+		},
+		func() float64 { return float64(spansPending.Load()) },
+	))
+	describeMetric("app1_telemetry_span_queue_size", metricMeta{Unit: "none", Thresholds: []float64{spanQueueSize / 2, spanQueueSize}})
+
+	otel.SetErrorHandler(otel.ErrorHandlerFunc(func(err error) {
+		telemetryErrors.Inc()
+		logMessage("warn", "OpenTelemetry error: "+err.Error(), "")
+	}))
+}
+
+// instrumentedExporter mide cada exportación de spans hacia Tempo. Si la
+// exportación falla y hay buffer local, guarda el lote en disco; cada
+// exportación exitosa despierta al worker que lo reenvía.
+type instrumentedExporter struct {
+	trace.SpanExporter
+	spool *spanSpool
+}
+
+func (e instrumentedExporter) ExportSpans(ctx context.Context, spans []trace.ReadOnlySpan) error {
+	err := e.export(ctx, spans)
+	spansPending.Add(-int64(len(spans)))
+
+	if err != nil {
+		if e.spool != nil {
+			spoolErr := e.spool.write(spans)
+			if spoolErr == nil {
+				spansBuffered.Add(float64(len(spans)))
+				return err
+			}
+			logMessage("warn", "Could not buffer spans: "+spoolErr.Error(), "")
+		}
+		spansDropped.WithLabelValues("export_failed").Add(float64(len(spans)))
+		return err
+	}
+
+	if e.spool != nil {
+		e.spool.notify()
+	}
+	return nil
+}
+
+func (e instrumentedExporter) export(ctx context.Context, spans []trace.ReadOnlySpan) error {
+	start := time.Now()
+	err := e.SpanExporter.ExportSpans(ctx, spans)
+
+	result := "success"
+	if err != nil {
+		result = "error"
+	} else {
+		spansExported.Add(float64(len(spans)))
+	}
+	exportDuration.WithLabelValues(result).Observe(time.Since(start).Seconds())
+	observeDependency("tempo", start, err)
+
+	return err
+}
+
+// boundedBatcher aplica delante del BatchSpanProcessor el mismo límite que
+// su cola. El batcher descarta en silencio cuando la cola está llena; acá los
+// spans que no entran se cuentan como descartados y no quedan pendientes.
+// spansPending incluye el lote que se está exportando, así que la cola del
+// batcher nunca llega a llenarse.
+type boundedBatcher struct {
+	trace.SpanProcessor
+}
+
+func newBoundedBatcher(exporter trace.SpanExporter) boundedBatcher {
+	return boundedBatcher{trace.NewBatchSpanProcessor(exporter, trace.WithMaxQueueSize(spanQueueSize))}
+}
+
+func (b boundedBatcher) OnEnd(s trace.ReadOnlySpan) {
+	if !s.SpanContext().IsSampled() {
+		return
+	}
+	if spansPending.Add(1) > spanQueueSize {
+		spansPending.Add(-1)
+		spansDropped.WithLabelValues("queue_full").Inc()
+		return
+	}
+	b.SpanProcessor.OnEnd(s)
+}
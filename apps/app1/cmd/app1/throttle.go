@@ -0,0 +1,118 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/procfs"
+)
+
+const maxThrottleFactor = 8
+
+var (
+	selfThrottled = prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "app1_self_throttled",
+			Help: "1 while background simulators are slowed down due to high CPU or memory usage",
+		},
+	)
+
+	selfThrottleFactor = prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "app1_self_throttle_factor",
+			Help: "Multiplier applied to background simulator intervals",
+		},
+	)
+)
+
+func init() {
+	prometheus.MustRegister(selfThrottled)
+	prometheus.MustRegister(selfThrottleFactor)
+	describeMetric("app1_self_throttled", metricMeta{Panel: "stat", Unit: "none", Thresholds: []float64{1}})
+}
+
+// resourceThrottler espacia los simuladores en background cuando el proceso
+// supera los umbrales de CPU o RSS, y los recupera al bajar la carga.
+type resourceThrottler struct {
+	cpuThreshold float64 // porcentaje de un core
+	rssThreshold int64   // bytes
+
+	factor      int
+	lastCPU     float64
+	lastSampled time.Time
+}
+
+func newResourceThrottler() *resourceThrottler {
+	t := &resourceThrottler{cpuThreshold: 80, rssThreshold: 100 << 20, factor: 1}
+
+	if v := os.Getenv("SIMULATOR_CPU_THRESHOLD"); v != "" {
+		if parsed, err := strconv.ParseFloat(v, 64); err == nil {
+			t.cpuThreshold = parsed
+		}
+	}
+	if v := os.Getenv("SIMULATOR_RSS_THRESHOLD_MB"); v != "" {
+		if parsed, err := strconv.ParseInt(v, 10, 64); err == nil {
+			t.rssThreshold = parsed << 20
+		}
+	}
+
+	selfThrottleFactor.Set(1)
+	return t
+}
+
+// Next devuelve el próximo intervalo para un simulador con intervalo base.
+func (t *resourceThrottler) Next(base time.Duration) time.Duration {
+	cpu, rss, ok := t.sample()
+	if !ok {
+		return base
+	}
+
+	previous := t.factor
+	if cpu > t.cpuThreshold || rss > t.rssThreshold {
+		if t.factor < maxThrottleFactor {
+			t.factor *= 2
+		}
+	} else if t.factor > 1 {
+		t.factor /= 2
+	}
+
+	if t.factor != previous {
+		logMessage("warn", fmt.Sprintf("Background simulator throttle factor %dx (cpu=%.1f%% rss=%dMB)", t.factor, cpu, rss>>20), "")
+	}
+
+	selfThrottleFactor.Set(float64(t.factor))
+	if t.factor > 1 {
+		selfThrottled.Set(1)
+	} else {
+		selfThrottled.Set(0)
+	}
+
+	return base * time.Duration(t.factor)
+}
+
+// sample devuelve el uso de CPU desde la muestra anterior y el RSS actual.
+// En sistemas sin /proc no hay muestra y no se aplica throttling.
+func (t *resourceThrottler) sample() (float64, int64, bool) {
+	proc, err := procfs.Self()
+	if err != nil {
+		return 0, 0, false
+	}
+	stat, err := proc.Stat()
+	if err != nil {
+		return 0, 0, false
+	}
+
+	now := time.Now()
+	cpuTime := stat.CPUTime()
+
+	cpu := 0.0
+	if !t.lastSampled.IsZero() {
+		cpu = (cpuTime - t.lastCPU) / now.Sub(t.lastSampled).Seconds() * 100
+	}
+	t.lastCPU, t.lastSampled = cpuTime, now
+
+	return cpu, int64(stat.ResidentMemory()), true
+}
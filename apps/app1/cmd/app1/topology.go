@@ -0,0 +1,91 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+)
+
+// dependency es una dependencia declarada del servicio. A diferencia del
+// service graph de Tempo, existe aunque todavía no haya tráfico hacia ella.
+type dependency struct {
+	Name        string `json:"name"`
+	Protocol    string `json:"protocol"`
+	Criticality string `json:"criticality"`
+}
+
+// topology es la respuesta de /admin/topology; app2 expone el mismo esquema.
+type topology struct {
+	Service      string       `json:"service"`
+	Version      string       `json:"version"`
+	InstanceID   string       `json:"instance_id"`
+	Dependencies []dependency `json:"dependencies"`
+}
+
+var declaredDependencies = loadDependencies()
+
+// defaultDependencies refleja las llamadas que hace app1 hoy.
+func defaultDependencies() []dependency {
+	deps := []dependency{
+		{Name: "external-service", Protocol: "http", Criticality: "critical"},
+		{Name: "recommendations", Protocol: "http", Criticality: "optional"},
+		{Name: "tempo", Protocol: "otlp-http", Criticality: "optional"},
+	}
+	if os.Getenv("PROMETHEUS_URL") != "" {
+		deps = append(deps, dependency{Name: "prometheus", Protocol: "http", Criticality: "optional"})
+	}
+	return deps
+}
+
+// loadDependencies lee DEPENDENCIES con el formato
+// "nombre=protocolo:criticidad,..." (criticidad critical u optional).
+func loadDependencies() []dependency {
+	value := os.Getenv("DEPENDENCIES")
+	if value == "" {
+		return defaultDependencies()
+	}
+
+	deps, err := parseDependencies(value)
+	if err != nil {
+		logMessage("warn", "Invalid DEPENDENCIES, using defaults: "+err.Error(), "")
+		return defaultDependencies()
+	}
+	return deps
+}
+
+func parseDependencies(value string) ([]dependency, error) {
+	var deps []dependency
+	for _, entry := range strings.Split(value, ",") {
+		name, spec, ok := strings.Cut(strings.TrimSpace(entry), "=")
+		protocol, criticality, _ := strings.Cut(spec, ":")
+		if !ok || name == "" || protocol == "" {
+			return nil, fmt.Errorf("entry %q: expected name=protocol:criticality", entry)
+		}
+		if criticality == "" {
+			criticality = "critical"
+		}
+		if criticality != "critical" && criticality != "optional" {
+			return nil, fmt.Errorf("entry %q: criticality must be critical or optional", entry)
+		}
+		deps = append(deps, dependency{Name: name, Protocol: protocol, Criticality: criticality})
+	}
+	return deps, nil
+}
+
+func topologyHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.Header().Set("Allow", "GET")
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(topology{
+		Service:      "app1",
+		Version:      serviceVersion,
+		InstanceID:   instanceID,
+		Dependencies: declaredDependencies,
+	})
+}
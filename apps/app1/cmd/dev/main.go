@@ -0,0 +1,210 @@
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"io"
+	"io/fs"
+	"log"
+	"os"
+	"os/exec"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// Colores ANSI para distinguir cada servicio en el stream combinado
+var colors = []string{"\033[36m", "\033[35m", "\033[33m", "\033[32m", "\033[34m"}
+
+const colorReset = "\033[0m"
+
+// service es un ejecutable de cmd/ que el runner compila y reinicia.
+type service struct {
+	name   string
+	color  string
+	binary string
+	env    []string
+
+	mu   sync.Mutex
+	cmd  *exec.Cmd
+	done chan struct{}
+}
+
+var outputMu sync.Mutex
+
+// prefixOutput copia las líneas de r con el nombre del servicio coloreado.
+func prefixOutput(name, color string, r io.Reader) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		outputMu.Lock()
+		fmt.Printf("%s%-18s|%s %s\n", color, name, colorReset, scanner.Text())
+		outputMu.Unlock()
+	}
+}
+
+func (s *service) build() error {
+	cmd := exec.Command("go", "build", "-o", s.binary, "./cmd/"+s.name)
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		prefixOutput(s.name, s.color, strings.NewReader(string(out)))
+	}
+	return err
+}
+
+func (s *service) start() error {
+	cmd := exec.Command(s.binary)
+	cmd.Env = append(os.Environ(), s.env...)
+
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return err
+	}
+	stderr, err := cmd.StderrPipe()
+	if err != nil {
+		return err
+	}
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+
+	go prefixOutput(s.name, s.color, stdout)
+	go prefixOutput(s.name, s.color, stderr)
+
+	done := make(chan struct{})
+	go func() {
+		cmd.Wait()
+		close(done)
+	}()
+
+	s.mu.Lock()
+	s.cmd, s.done = cmd, done
+	s.mu.Unlock()
+
+	return nil
+}
+
+// stop envía SIGTERM y, si el proceso no termina a tiempo, SIGKILL.
+func (s *service) stop() {
+	s.mu.Lock()
+	cmd, done := s.cmd, s.done
+	s.cmd, s.done = nil, nil
+	s.mu.Unlock()
+
+	if cmd == nil {
+		return
+	}
+
+	cmd.Process.Signal(syscall.SIGTERM)
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		cmd.Process.Kill()
+		<-done
+	}
+}
+
+func (s *service) restart() {
+	s.stop()
+	if err := s.build(); err != nil {
+		prefixOutput(s.name, s.color, strings.NewReader("build failed, waiting for changes"))
+		return
+	}
+	if err := s.start(); err != nil {
+		prefixOutput(s.name, s.color, strings.NewReader("start failed: "+err.Error()))
+	}
+}
+
+// latestChange devuelve la modificación más reciente de los .go del módulo.
+func latestChange(root string) time.Time {
+	var latest time.Time
+	filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return nil
+		}
+		if d.IsDir() && path != root && strings.HasPrefix(d.Name(), ".") {
+			return filepath.SkipDir
+		}
+		if !d.IsDir() && (strings.HasSuffix(path, ".go") || d.Name() == "go.mod") {
+			if info, err := d.Info(); err == nil && info.ModTime().After(latest) {
+				latest = info.ModTime()
+			}
+		}
+		return nil
+	})
+	return latest
+}
+
+func main() {
+	services := flag.String("services", "app1,traffic-generator", "comma-separated cmd/ services to run")
+	interval := flag.Duration("interval", time.Second, "how often to check for file changes")
+	flag.Parse()
+
+	binDir, err := os.MkdirTemp("", "app1-dev")
+	if err != nil {
+		log.Fatalf("Error creating build directory: %v", err)
+	}
+	defer os.RemoveAll(binDir)
+
+	port := os.Getenv("PORT")
+	if port == "" {
+		port = "8080"
+	}
+
+	var running []*service
+	for i, name := range strings.Split(*services, ",") {
+		name = strings.TrimSpace(name)
+		if name == "" || name == "dev" {
+			continue
+		}
+
+		s := &service{
+			name:   name,
+			color:  colors[i%len(colors)],
+			binary: filepath.Join(binDir, name),
+		}
+
+		// Valores por defecto para correr en local; el entorno los sobrescribe
+		if name == "traffic-generator" && os.Getenv("TARGET_URL") == "" {
+			s.env = append(s.env, "TARGET_URL=http://localhost:"+port)
+		}
+		if name == "app1" && os.Getenv("TEMPO_ENDPOINT") == "" {
+			s.env = append(s.env, "TEMPO_ENDPOINT=http://localhost:4318")
+		}
+
+		running = append(running, s)
+	}
+
+	for _, s := range running {
+		s.restart()
+	}
+
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGINT, syscall.SIGTERM)
+
+	last := latestChange(".")
+	ticker := time.NewTicker(*interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			if changed := latestChange("."); changed.After(last) {
+				last = changed
+				log.Printf("Change detected, rebuilding %d services", len(running))
+				for _, s := range running {
+					s.restart()
+				}
+			}
+		case <-signals:
+			for _, s := range running {
+				s.stop()
+			}
+			return
+		}
+	}
+}
@@ -0,0 +1,365 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	dto "github.com/prometheus/client_model/go"
+	"github.com/prometheus/common/expfmt"
+)
+
+// Prefijos de métricas del runtime que no aportan al dashboard del servicio
+var runtimePrefixes = []string{"go_", "process_", "promhttp_"}
+
+// metricMeta es la descripción que app1 publica en /admin/metrics/metadata
+// junto al registro de cada métrica.
+type metricMeta struct {
+	Panel        string    `json:"panel"`
+	Unit         string    `json:"unit"`
+	Thresholds   []float64 `json:"thresholds"`
+	LowerIsWorse bool      `json:"lower_is_worse"`
+}
+
+type panelTarget struct {
+	Expr         string `json:"expr"`
+	LegendFormat string `json:"legendFormat"`
+	RefID        string `json:"refId"`
+}
+
+type gridPos struct {
+	H int `json:"h"`
+	W int `json:"w"`
+	X int `json:"x"`
+	Y int `json:"y"`
+}
+
+type panel struct {
+	ID          int                    `json:"id"`
+	Title       string                 `json:"title"`
+	Description string                 `json:"description,omitempty"`
+	Type        string                 `json:"type"`
+	Targets     []panelTarget          `json:"targets"`
+	FieldConfig map[string]interface{} `json:"fieldConfig"`
+	GridPos     gridPos                `json:"gridPos"`
+}
+
+type dashboard struct {
+	ID       *int     `json:"id"`
+	UID      string   `json:"uid"`
+	Title    string   `json:"title"`
+	Tags     []string `json:"tags"`
+	Timezone string   `json:"timezone"`
+	Refresh  string   `json:"refresh"`
+	Time     struct {
+		From string `json:"from"`
+		To   string `json:"to"`
+	} `json:"time"`
+	Panels []panel `json:"panels"`
+}
+
+// runDashboard lee /metrics de un servicio y emite un dashboard de Grafana
+// con un panel por métrica, para que el dashboard siga a lo que se emite.
+// Tipo de panel, unidad y umbrales salen de la metadata que registra el
+// servicio; para las métricas sin metadata se deducen del tipo y el sufijo.
+func runDashboard(args []string) {
+	fs := flag.NewFlagSet("dashboard", flag.ExitOnError)
+	metricsURL := fs.String("metrics-url", "http://localhost:8080/metrics", "metrics endpoint of the service")
+	metadataURL := fs.String("metadata-url", "", "metric metadata endpoint (default: /admin/metrics/metadata next to --metrics-url)")
+	job := fs.String("job", "app1", "Prometheus job label used in the generated queries")
+	title := fs.String("title", "", "dashboard title (default: <job> Generated Dashboard)")
+	includeRuntime := fs.Bool("include-runtime", false, "include go_*, process_* and promhttp_* metrics")
+	fs.Parse(args)
+
+	families, err := fetchMetricFamilies(*metricsURL)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "dashboard: %v\n", err)
+		os.Exit(1)
+	}
+
+	if *metadataURL == "" {
+		*metadataURL = strings.TrimSuffix(*metricsURL, "/metrics") + "/admin/metrics/metadata"
+	}
+	metadata, err := fetchMetricMetadata(*metadataURL)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "dashboard: no metric metadata (%v), guessing panels from metric names\n", err)
+	}
+
+	if *title == "" {
+		*title = *job + " Generated Dashboard"
+	}
+
+	d := buildDashboard(families, metadata, *job, *title, *includeRuntime)
+
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	enc.SetEscapeHTML(false)
+	enc.Encode(d)
+}
+
+func fetchMetricFamilies(metricsURL string) (map[string]*dto.MetricFamily, error) {
+	client := &http.Client{Timeout: 10 * time.Second}
+	req, err := http.NewRequest(http.MethodGet, metricsURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	// Pedimos el formato de texto para no depender de la negociación de protobuf
+	req.Header.Set("Accept", "text/plain")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("fetching metrics: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("metrics endpoint returned status %d", resp.StatusCode)
+	}
+
+	var parser expfmt.TextParser
+	families, err := parser.TextToMetricFamilies(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("parsing metrics: %w", err)
+	}
+	return families, nil
+}
+
+func fetchMetricMetadata(metadataURL string) (map[string]metricMeta, error) {
+	client := &http.Client{Timeout: 10 * time.Second}
+	resp, err := client.Get(metadataURL)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("metadata endpoint returned status %d", resp.StatusCode)
+	}
+
+	var metadata map[string]metricMeta
+	if err := json.NewDecoder(resp.Body).Decode(&metadata); err != nil {
+		return nil, fmt.Errorf("parsing metadata: %w", err)
+	}
+	return metadata, nil
+}
+
+func buildDashboard(families map[string]*dto.MetricFamily, metadata map[string]metricMeta, job, title string, includeRuntime bool) dashboard {
+	d := dashboard{
+		UID:      "generated-" + job,
+		Title:    title,
+		Tags:     []string{job, "generated"},
+		Timezone: "browser",
+		Refresh:  "30s",
+	}
+	d.Time.From, d.Time.To = "now-1h", "now"
+
+	names := make([]string, 0, len(families))
+	for name := range families {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		if !includeRuntime && hasAnyPrefix(name, runtimePrefixes) {
+			continue
+		}
+
+		p, ok := panelFor(families[name], metadata[name], job)
+		if !ok {
+			continue
+		}
+
+		// Dos paneles por fila
+		i := len(d.Panels)
+		p.ID = i + 1
+		p.GridPos = gridPos{H: 8, W: 12, X: (i % 2) * 12, Y: (i / 2) * 8}
+		d.Panels = append(d.Panels, p)
+	}
+
+	return d
+}
+
+func panelFor(mf *dto.MetricFamily, meta metricMeta, job string) (panel, bool) {
+	name := mf.GetName()
+	labels := labelNames(mf)
+	selector := fmt.Sprintf(`{job=%q}`, job)
+	by := ""
+	legend := name
+	if len(labels) > 0 {
+		by = " by (" + strings.Join(labels, ", ") + ")"
+		legend = "{{" + strings.Join(labels, "}} {{") + "}}"
+	}
+
+	p := panel{Title: name, Description: mf.GetHelp(), Type: "timeseries"}
+
+	switch mf.GetType() {
+	case dto.MetricType_COUNTER:
+		p.Title = strings.TrimSuffix(name, "_total") + " rate"
+		p.Targets = []panelTarget{{
+			Expr:         fmt.Sprintf("sum%s (rate(%s%s[5m]))", by, name, selector),
+			LegendFormat: legend,
+			RefID:        "A",
+		}}
+		p.FieldConfig = fieldConfig(metaOr(meta.Unit, rateUnit(name)), meta)
+
+	case dto.MetricType_GAUGE:
+		// Las métricas _info solo llevan labels, no tiene sentido graficarlas
+		if strings.HasSuffix(name, "_info") {
+			return panel{}, false
+		}
+		p.Targets = []panelTarget{{
+			Expr:         fmt.Sprintf("sum%s (%s%s)", by, name, selector),
+			LegendFormat: legend,
+			RefID:        "A",
+		}}
+		if len(labels) == 0 {
+			p.Type = "stat"
+		}
+		p.FieldConfig = fieldConfig(metaOr(meta.Unit, valueUnit(name)), meta)
+
+	case dto.MetricType_HISTOGRAM:
+		// Una serie por percentil y combinación de labels (p. ej. por route)
+		byLe := " by (" + strings.Join(append([]string{"le"}, labels...), ", ") + ")"
+		for i, q := range []string{"50", "95", "99"} {
+			qLegend := "p" + q
+			if len(labels) > 0 {
+				qLegend += " " + legend
+			}
+			p.Targets = append(p.Targets, panelTarget{
+				Expr:         fmt.Sprintf("histogram_quantile(0.%s, sum%s (rate(%s_bucket%s[5m])))", q, byLe, name, selector),
+				LegendFormat: qLegend,
+				RefID:        string(rune('A' + i)),
+			})
+		}
+		p.FieldConfig = fieldConfig(metaOr(meta.Unit, valueUnit(name)), meta)
+
+	case dto.MetricType_SUMMARY:
+		p.Targets = []panelTarget{{
+			Expr:         fmt.Sprintf("%s%s", name, selector),
+			LegendFormat: "{{quantile}}",
+			RefID:        "A",
+		}}
+		p.FieldConfig = fieldConfig(metaOr(meta.Unit, valueUnit(name)), meta)
+
+	default:
+		return panel{}, false
+	}
+
+	if meta.Panel != "" {
+		p.Type = meta.Panel
+	}
+	return p, true
+}
+
+// labelNames devuelve las labels presentes en la familia, sin las que
+// agrega Prometheus o que son propias de histogramas y summaries.
+func labelNames(mf *dto.MetricFamily) []string {
+	seen := make(map[string]bool)
+	for _, m := range mf.GetMetric() {
+		for _, l := range m.GetLabel() {
+			switch l.GetName() {
+			case "le", "quantile", "job", "instance":
+				continue
+			}
+			seen[l.GetName()] = true
+		}
+	}
+
+	names := make([]string, 0, len(seen))
+	for name := range seen {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// metaOr devuelve el valor de la metadata o, si no está, el deducido.
+func metaOr(value, guessed string) string {
+	if value != "" {
+		return value
+	}
+	return guessed
+}
+
+// rateUnit y valueUnit deducen la unidad del nombre cuando la métrica no
+// tiene metadata registrada.
+func rateUnit(name string) string {
+	switch {
+	case strings.HasSuffix(name, "_seconds_total"):
+		return "s"
+	case strings.HasSuffix(name, "_bytes_total"):
+		return "Bps"
+	case strings.Contains(name, "requests"):
+		return "reqps"
+	default:
+		return "ops"
+	}
+}
+
+func valueUnit(name string) string {
+	switch {
+	case strings.HasSuffix(name, "_seconds"):
+		return "s"
+	case strings.HasSuffix(name, "_bytes"):
+		return "bytes"
+	case strings.HasSuffix(name, "_ratio"):
+		return "percentunit"
+	default:
+		return "short"
+	}
+}
+
+func fieldConfig(unit string, meta metricMeta) map[string]interface{} {
+	defaults := map[string]interface{}{
+		"unit":     unit,
+		"mappings": []interface{}{},
+	}
+	if len(meta.Thresholds) > 0 {
+		defaults["thresholds"] = map[string]interface{}{
+			"mode":  "absolute",
+			"steps": thresholdSteps(meta.Thresholds, meta.LowerIsWorse),
+		}
+		// En series temporales los umbrales se dibujan como líneas
+		defaults["custom"] = map[string]interface{}{
+			"thresholdsStyle": map[string]interface{}{"mode": "line"},
+		}
+	}
+
+	return map[string]interface{}{
+		"defaults":  defaults,
+		"overrides": []interface{}{},
+	}
+}
+
+// thresholdSteps pasa de verde a rojo a medida que se cruzan los umbrales,
+// o al revés si un valor más bajo es peor.
+func thresholdSteps(thresholds []float64, lowerIsWorse bool) []interface{} {
+	colors := make([]string, len(thresholds)+1)
+	for i := range colors {
+		colors[i] = "yellow"
+	}
+	colors[0], colors[len(colors)-1] = "green", "red"
+	if lowerIsWorse {
+		colors[0], colors[len(colors)-1] = "red", "green"
+	}
+
+	steps := []interface{}{map[string]interface{}{"color": colors[0], "value": nil}}
+	for i, t := range thresholds {
+		steps = append(steps, map[string]interface{}{"color": colors[i+1], "value": t})
+	}
+	return steps
+}
+
+func hasAnyPrefix(s string, prefixes []string) bool {
+	for _, p := range prefixes {
+		if strings.HasPrefix(s, p) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,38 @@
+package main
+
+import (
+	"fmt"
+	"os"
+)
+
+// labctl agrupa utilidades de línea de comandos para operar el lab.
+func usage() {
+	fmt.Fprintln(os.Stderr, "usage: labctl <command> [flags]")
+	fmt.Fprintln(os.Stderr)
+	fmt.Fprintln(os.Stderr, "commands:")
+	fmt.Fprintln(os.Stderr, "  trace-logs <trace_id>   print the Loki log lines correlated with a trace")
+	fmt.Fprintln(os.Stderr, "  dashboard               generate Grafana dashboard JSON from a service's /metrics")
+	fmt.Fprintln(os.Stderr, "  topology                build a service map from each service's /admin/topology")
+}
+
+func main() {
+	if len(os.Args) < 2 {
+		usage()
+		os.Exit(2)
+	}
+
+	switch os.Args[1] {
+	case "trace-logs":
+		runTraceLogs(os.Args[2:])
+	case "dashboard":
+		runDashboard(os.Args[2:])
+	case "topology":
+		runTopology(os.Args[2:])
+	case "help", "-h", "--help":
+		usage()
+	default:
+		fmt.Fprintf(os.Stderr, "labctl: unknown command %q\n\n", os.Args[1])
+		usage()
+		os.Exit(2)
+	}
+}
@@ -0,0 +1,153 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+	"time"
+)
+
+// serviceTopology es la respuesta de /admin/topology de cada servicio.
+type serviceTopology struct {
+	Service      string `json:"service"`
+	Version      string `json:"version"`
+	InstanceID   string `json:"instance_id"`
+	Dependencies []struct {
+		Name        string `json:"name"`
+		Protocol    string `json:"protocol"`
+		Criticality string `json:"criticality"`
+	} `json:"dependencies"`
+}
+
+type topologyEdge struct {
+	From        string `json:"from"`
+	To          string `json:"to"`
+	Protocol    string `json:"protocol"`
+	Criticality string `json:"criticality"`
+}
+
+// serviceMap es el mapa agregado: servicios consultados, dependencias
+// como aristas y los endpoints que no respondieron.
+type serviceMap struct {
+	Services []serviceTopology `json:"services"`
+	Edges    []topologyEdge    `json:"edges"`
+	Errors   map[string]string `json:"errors,omitempty"`
+}
+
+// runTopology consulta /admin/topology en cada servicio y arma el mapa de
+// dependencias declaradas en JSON o en DOT (Graphviz).
+func runTopology(args []string) {
+	fs := flag.NewFlagSet("topology", flag.ExitOnError)
+	services := fs.String("services", envOr("TOPOLOGY_SERVICES", "http://localhost:8080,http://localhost:8000"), "comma-separated base URLs of the services to query")
+	output := fs.String("output", "json", "output format: json or dot")
+	fs.Parse(args)
+
+	if *output != "json" && *output != "dot" {
+		fmt.Fprintf(os.Stderr, "topology: invalid --output %q\n", *output)
+		os.Exit(2)
+	}
+
+	m := collectTopology(strings.Split(*services, ","))
+	for base, err := range m.Errors {
+		fmt.Fprintf(os.Stderr, "topology: %s: %s\n", base, err)
+	}
+	if len(m.Services) == 0 {
+		fmt.Fprintln(os.Stderr, "topology: no service answered")
+		os.Exit(1)
+	}
+
+	if *output == "dot" {
+		fmt.Print(m.dot())
+		return
+	}
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	enc.Encode(m)
+}
+
+func collectTopology(bases []string) serviceMap {
+	client := &http.Client{Timeout: 5 * time.Second}
+	m := serviceMap{Errors: make(map[string]string)}
+
+	// Varias réplicas del mismo servicio declaran las mismas aristas
+	seen := make(map[topologyEdge]bool)
+	for _, base := range bases {
+		base = strings.TrimRight(strings.TrimSpace(base), "/")
+		if base == "" {
+			continue
+		}
+
+		t, err := fetchTopology(client, base)
+		if err != nil {
+			m.Errors[base] = err.Error()
+			continue
+		}
+		m.Services = append(m.Services, t)
+
+		for _, d := range t.Dependencies {
+			edge := topologyEdge{From: t.Service, To: d.Name, Protocol: d.Protocol, Criticality: d.Criticality}
+			if !seen[edge] {
+				seen[edge] = true
+				m.Edges = append(m.Edges, edge)
+			}
+		}
+	}
+
+	sort.Slice(m.Edges, func(i, j int) bool {
+		if m.Edges[i].From != m.Edges[j].From {
+			return m.Edges[i].From < m.Edges[j].From
+		}
+		return m.Edges[i].To < m.Edges[j].To
+	})
+	return m
+}
+
+func fetchTopology(client *http.Client, base string) (serviceTopology, error) {
+	var t serviceTopology
+
+	resp, err := client.Get(base + "/admin/topology")
+	if err != nil {
+		return t, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return t, fmt.Errorf("status %d", resp.StatusCode)
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&t); err != nil {
+		return t, fmt.Errorf("decoding topology: %w", err)
+	}
+	if t.Service == "" {
+		return t, fmt.Errorf("response has no service name")
+	}
+	return t, nil
+}
+
+// dot dibuja los servicios consultados como cajas y las dependencias
+// opcionales con línea punteada.
+func (m serviceMap) dot() string {
+	var b strings.Builder
+	b.WriteString("digraph topology {\n  rankdir=LR;\n  node [shape=ellipse];\n")
+
+	drawn := make(map[string]bool)
+	for _, s := range m.Services {
+		if !drawn[s.Service] {
+			drawn[s.Service] = true
+			fmt.Fprintf(&b, "  %q [shape=box];\n", s.Service)
+		}
+	}
+	for _, e := range m.Edges {
+		style := "solid"
+		if e.Criticality == "optional" {
+			style = "dashed"
+		}
+		fmt.Fprintf(&b, "  %q -> %q [label=%q, style=%s];\n", e.From, e.To, e.Protocol, style)
+	}
+
+	b.WriteString("}\n")
+	return b.String()
+}
@@ -0,0 +1,163 @@
+package main
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// logLine es una línea de Loki junto con las labels de su stream.
+type logLine struct {
+	Timestamp time.Time         `json:"timestamp"`
+	Labels    map[string]string `json:"labels"`
+	Line      string            `json:"line"`
+}
+
+// lokiResponse cubre solo la parte de query_range que usamos (streams).
+type lokiResponse struct {
+	Status string `json:"status"`
+	Data   struct {
+		ResultType string `json:"resultType"`
+		Result     []struct {
+			Stream map[string]string `json:"stream"`
+			Values [][2]string       `json:"values"`
+		} `json:"result"`
+	} `json:"data"`
+}
+
+// Labels que identifican al servicio en la salida de texto, en orden de preferencia
+var serviceLabels = []string{"app", "service_name", "service", "container", "job"}
+
+// runTraceLogs busca en Loki las líneas que contienen el trace_id en todos
+// los streams del selector y las imprime ordenadas por timestamp.
+func runTraceLogs(args []string) {
+	fs := flag.NewFlagSet("trace-logs", flag.ExitOnError)
+	lokiURL := fs.String("loki", envOr("LOKI_URL", "http://localhost:3100"), "Loki base URL")
+	selector := fs.String("selector", `{job="fluent-bit"}`, "LogQL stream selector to search")
+	since := fs.Duration("since", time.Hour, "how far back to search")
+	limit := fs.Int("limit", 1000, "maximum number of lines to return")
+	output := fs.String("output", "text", "output format: text or json")
+
+	// El trace_id puede ir antes o después de los flags
+	var traceID string
+	if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		traceID, args = args[0], args[1:]
+	}
+	fs.Parse(args)
+	if traceID == "" && fs.NArg() > 0 {
+		traceID = fs.Arg(0)
+	}
+
+	traceID = strings.ToLower(strings.TrimSpace(traceID))
+	if !validTraceID(traceID) {
+		fmt.Fprintln(os.Stderr, "trace-logs: a 32-character hex trace_id is required")
+		fs.Usage()
+		os.Exit(2)
+	}
+	if *output != "text" && *output != "json" {
+		fmt.Fprintf(os.Stderr, "trace-logs: invalid --output %q\n", *output)
+		os.Exit(2)
+	}
+
+	end := time.Now()
+	lines, err := queryTraceLogs(*lokiURL, *selector, traceID, end.Add(-*since), end, *limit)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "trace-logs: %v\n", err)
+		os.Exit(1)
+	}
+
+	if *output == "json" {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetEscapeHTML(false)
+		for _, l := range lines {
+			enc.Encode(l)
+		}
+		return
+	}
+
+	if len(lines) == 0 {
+		fmt.Fprintf(os.Stderr, "No log lines found for trace %s in the last %s\n", traceID, *since)
+		return
+	}
+	for _, l := range lines {
+		fmt.Printf("%s %-20s %s\n", l.Timestamp.Format(time.RFC3339Nano), serviceName(l.Labels), l.Line)
+	}
+}
+
+func queryTraceLogs(base, selector, traceID string, start, end time.Time, limit int) ([]logLine, error) {
+	params := url.Values{}
+	params.Set("query", fmt.Sprintf("%s |= %q", selector, traceID))
+	params.Set("start", strconv.FormatInt(start.UnixNano(), 10))
+	params.Set("end", strconv.FormatInt(end.UnixNano(), 10))
+	params.Set("limit", strconv.Itoa(limit))
+	params.Set("direction", "forward")
+
+	client := &http.Client{Timeout: 30 * time.Second}
+	resp, err := client.Get(strings.TrimRight(base, "/") + "/loki/api/v1/query_range?" + params.Encode())
+	if err != nil {
+		return nil, fmt.Errorf("querying Loki: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("Loki returned status %d", resp.StatusCode)
+	}
+
+	var body lokiResponse
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return nil, fmt.Errorf("decoding Loki response: %w", err)
+	}
+	if body.Status != "success" || body.Data.ResultType != "streams" {
+		return nil, fmt.Errorf("unexpected Loki response (status %q, type %q)", body.Status, body.Data.ResultType)
+	}
+
+	// Loki ordena dentro de cada stream; hay que mezclar todos los servicios
+	var lines []logLine
+	for _, stream := range body.Data.Result {
+		for _, v := range stream.Values {
+			ns, err := strconv.ParseInt(v[0], 10, 64)
+			if err != nil {
+				continue
+			}
+			lines = append(lines, logLine{Timestamp: time.Unix(0, ns).UTC(), Labels: stream.Stream, Line: v[1]})
+		}
+	}
+	sort.SliceStable(lines, func(i, j int) bool { return lines[i].Timestamp.Before(lines[j].Timestamp) })
+
+	return lines, nil
+}
+
+func validTraceID(id string) bool {
+	if len(id) != 32 {
+		return false
+	}
+	_, err := hex.DecodeString(id)
+	return err == nil
+}
+
+func serviceName(labels map[string]string) string {
+	for _, key := range serviceLabels {
+		if v := labels[key]; v != "" {
+			if cluster := labels["cluster"]; cluster != "" && key != "job" {
+				return cluster + "/" + v
+			}
+			return v
+		}
+	}
+	return "-"
+}
+
+func envOr(key, fallback string) string {
+	if v := os.Getenv(key); v != "" {
+		return v
+	}
+	return fallback
+}
@@ -0,0 +1,342 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"math/rand"
+	"net"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"time"
+)
+
+// racecheck compila app1 y el generador de tráfico con -race, los somete a
+// tráfico concurrente sobre todas las rutas mientras recarga configuración
+// y cambia el muestreo en caliente, y falla si el detector reporta algún
+// data race. Complementa al test de cmd/app1 (go test -race): acá corren los
+// binarios reales, con socket unix, SIGHUP y apagado por SIGTERM.
+
+const raceMarker = "WARNING: DATA RACE"
+
+// Rutas a ejercitar, con el método que usa cada una
+var routes = []struct{ method, path string }{
+	{http.MethodGet, "/data"},
+	{http.MethodGet, "/data"},
+	{http.MethodGet, "/data"},
+	{http.MethodGet, "/health"},
+	{http.MethodGet, "/metrics"},
+	{http.MethodGet, "/peers"},
+	{http.MethodGet, "/usage"},
+	{http.MethodGet, "/admin/config"},
+	{http.MethodGet, "/admin/topology"},
+	{http.MethodGet, "/admin/health-score"},
+	{http.MethodGet, "/admin/tracing/sampling"},
+	{http.MethodPost, "/admin/alerts"},
+	{http.MethodGet, "/not-found"},
+}
+
+var userAgents = []string{
+	"Mozilla/5.0 (X11; Linux x86_64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0 Safari/537.36",
+	"curl/8.4.0",
+	"",
+}
+
+// alertPayload es un webhook mínimo de Alertmanager
+const alertPayload = `{"version":"4","status":"firing","receiver":"app1","alerts":[{"status":"firing","labels":{"alertname":"RaceCheck","severity":"info"},"annotations":{}}]}`
+
+// process es un binario compilado con -race y su salida combinada.
+type process struct {
+	name string
+	cmd  *exec.Cmd
+	done chan struct{}
+
+	mu     sync.Mutex
+	output bytes.Buffer
+}
+
+func (p *process) Write(b []byte) (int, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.output.Write(b)
+}
+
+func (p *process) races() []string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	// Cada reporte del detector va entre líneas de "=================="
+	var reports []string
+	var current []string
+	inReport := false
+	scanner := bufio.NewScanner(bytes.NewReader(p.output.Bytes()))
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := scanner.Text()
+		switch {
+		case line == raceMarker:
+			inReport, current = true, []string{line}
+		case inReport && strings.HasPrefix(line, "=================="):
+			reports = append(reports, strings.Join(current, "\n"))
+			inReport = false
+		case inReport:
+			current = append(current, line)
+		}
+	}
+	return reports
+}
+
+func build(dir, name string) (string, error) {
+	binary := filepath.Join(dir, name)
+	cmd := exec.Command("go", "build", "-race", "-o", binary, "./cmd/"+name)
+	// El detector de races necesita cgo
+	cmd.Env = append(os.Environ(), "CGO_ENABLED=1")
+	if out, err := cmd.CombinedOutput(); err != nil {
+		return "", fmt.Errorf("building %s: %v\n%s", name, err, out)
+	}
+	return binary, nil
+}
+
+func start(name, binary string, env []string) (*process, error) {
+	p := &process{name: name, done: make(chan struct{})}
+	p.cmd = exec.Command(binary)
+	p.cmd.Env = append(os.Environ(), env...)
+	p.cmd.Env = append(p.cmd.Env, "GORACE=halt_on_error=0")
+	p.cmd.Stdout = p
+	p.cmd.Stderr = p
+
+	if err := p.cmd.Start(); err != nil {
+		return nil, err
+	}
+	go func() {
+		p.cmd.Wait()
+		close(p.done)
+	}()
+	return p, nil
+}
+
+// stop envía SIGTERM para pasar también por el apagado ordenado.
+func (p *process) stop(timeout time.Duration) {
+	p.cmd.Process.Signal(syscall.SIGTERM)
+	select {
+	case <-p.done:
+	case <-time.After(timeout):
+		log.Printf("%s did not stop after %s, killing it", p.name, timeout)
+		p.cmd.Process.Kill()
+		<-p.done
+	}
+}
+
+func waitHealthy(url string, timeout time.Duration) error {
+	deadline := time.Now().Add(timeout)
+	for time.Now().Before(deadline) {
+		resp, err := http.Get(url + "/health")
+		if err == nil {
+			resp.Body.Close()
+			if resp.StatusCode == http.StatusOK {
+				return nil
+			}
+		}
+		time.Sleep(500 * time.Millisecond)
+	}
+	return fmt.Errorf("app1 not healthy after %s", timeout)
+}
+
+// hammer envía requests con headers variados (navegador, bot, API keys,
+// IPs simuladas) por TCP y por el socket unix hasta que vence el contexto.
+func hammer(ctx context.Context, tcpURL, socket string, sent, failed *atomic.Int64) {
+	tcp := &http.Client{Timeout: 10 * time.Second}
+	unix := &http.Client{
+		Timeout: 10 * time.Second,
+		Transport: &http.Transport{
+			DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+				var d net.Dialer
+				return d.DialContext(ctx, "unix", socket)
+			},
+		},
+	}
+
+	for ctx.Err() == nil {
+		route := routes[rand.Intn(len(routes))]
+		client, base := tcp, tcpURL
+		if rand.Intn(4) == 0 {
+			client, base = unix, "http://app1"
+		}
+
+		var body io.Reader
+		if route.method == http.MethodPost {
+			body = strings.NewReader(alertPayload)
+		}
+		req, err := http.NewRequestWithContext(ctx, route.method, base+route.path, body)
+		if err != nil {
+			continue
+		}
+		req.Header.Set("User-Agent", userAgents[rand.Intn(len(userAgents))])
+		if rand.Intn(2) == 0 {
+			req.Header.Set("Accept-Language", "es-AR")
+			req.Header.Set("Accept", "application/json")
+		}
+		if rand.Intn(3) == 0 {
+			req.Header.Set("X-API-Key", "key-"+strconv.Itoa(rand.Intn(5)))
+		}
+		req.Header.Set("X-Forwarded-For", fmt.Sprintf("100.%d.%d.%d", 64+rand.Intn(8), rand.Intn(256), 1+rand.Intn(254)))
+
+		sent.Add(1)
+		resp, err := client.Do(req)
+		if err != nil {
+			if ctx.Err() == nil {
+				failed.Add(1)
+			}
+			continue
+		}
+		io.Copy(io.Discard, resp.Body)
+		resp.Body.Close()
+	}
+}
+
+// churn cambia la configuración en caliente: muestreo por la API admin y
+// claves recargables por CONFIG_FILE + SIGHUP.
+func churn(ctx context.Context, app *process, url, configFile string, reloads *atomic.Int64) {
+	client := &http.Client{Timeout: 5 * time.Second}
+	actions := []string{"tag", "tarpit", "tag"}
+	levels := []string{"info", "warn", "debug"}
+
+	ticker := time.NewTicker(2 * time.Second)
+	defer ticker.Stop()
+
+	for i := 0; ; i++ {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		ratio := fmt.Sprintf(`{"ratio":%.2f}`, rand.Float64())
+		if req, err := http.NewRequest(http.MethodPut, url+"/admin/tracing/sampling", strings.NewReader(ratio)); err == nil {
+			if resp, err := client.Do(req); err == nil {
+				resp.Body.Close()
+			}
+		}
+
+		config := fmt.Sprintf("LOG_LEVEL=%s\nBOT_ACTION=%s\nBOT_TARPIT_DELAY=50ms\nACCESS_LOG_SAMPLE=%.1f\nDEPENDENCY_ERROR_RATES=recommendations=%.2f\nCLIENT_QUOTAS=key-1=%d\n",
+			levels[i%len(levels)], actions[i%len(actions)], rand.Float64(), rand.Float64()/2, 50+rand.Intn(500))
+		if err := os.WriteFile(configFile, []byte(config), 0o644); err != nil {
+			log.Printf("Error writing config file: %v", err)
+			continue
+		}
+		app.cmd.Process.Signal(syscall.SIGHUP)
+		reloads.Add(1)
+	}
+}
+
+func main() {
+	duration := flag.Duration("duration", 45*time.Second, "how long to generate concurrent load")
+	workers := flag.Int("workers", 16, "concurrent request workers")
+	port := flag.Int("port", 18090, "port for app1 (the traffic generator metrics use port+1)")
+	flag.Parse()
+
+	dir, err := os.MkdirTemp("", "app1-racecheck")
+	if err != nil {
+		log.Fatalf("Error creating work directory: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	log.Printf("Building app1 and traffic-generator with -race")
+	appBinary, err := build(dir, "app1")
+	if err != nil {
+		log.Fatal(err)
+	}
+	generatorBinary, err := build(dir, "traffic-generator")
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	url := fmt.Sprintf("http://127.0.0.1:%d", *port)
+	socket := filepath.Join(dir, "app1.sock")
+	configFile := filepath.Join(dir, "app1.conf")
+	if err := os.WriteFile(configFile, []byte("LOG_LEVEL=info\n"), 0o644); err != nil {
+		log.Fatalf("Error writing config file: %v", err)
+	}
+
+	// Tempo y Prometheus inaccesibles a propósito: ejercitan el buffer de
+	// spans, los reintentos y el health score con errores.
+	app, err := start("app1", appBinary, []string{
+		"PORT=" + strconv.Itoa(*port),
+		"UNIX_SOCKET=" + socket,
+		"CONFIG_FILE=" + configFile,
+		"TEMPO_ENDPOINT=http://127.0.0.1:1",
+		"PROMETHEUS_URL=http://127.0.0.1:1",
+		"SPAN_BUFFER_DIR=" + filepath.Join(dir, "spans"),
+		"SPAN_BUFFER_MAX_MB=1",
+		"ACCESS_LOG_SINK=file:" + filepath.Join(dir, "access.log"),
+		"CLIENT_DAILY_QUOTA=100000",
+		"SHUTDOWN_TIMEOUT=10s",
+	})
+	if err != nil {
+		log.Fatalf("Error starting app1: %v", err)
+	}
+	if err := waitHealthy(url, 60*time.Second); err != nil {
+		app.stop(5 * time.Second)
+		log.Fatal(err)
+	}
+
+	generator, err := start("traffic-generator", generatorBinary, []string{
+		"TARGET_URL=" + url,
+		"METRICS_PORT=" + strconv.Itoa(*port+1),
+		"BAD_ACTOR_RATE=30",
+		"SIMULATE_CLIENT_IPS=true",
+	})
+	if err != nil {
+		app.stop(5 * time.Second)
+		log.Fatalf("Error starting traffic-generator: %v", err)
+	}
+
+	log.Printf("Running %d workers against %s for %s", *workers, url, *duration)
+	ctx, cancel := context.WithTimeout(context.Background(), *duration)
+	defer cancel()
+
+	var sent, failed, reloads atomic.Int64
+	var wg sync.WaitGroup
+	for i := 0; i < *workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			hammer(ctx, url, socket, &sent, &failed)
+		}()
+	}
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		churn(ctx, app, url, configFile, &reloads)
+	}()
+	wg.Wait()
+
+	generator.stop(10 * time.Second)
+	app.stop(20 * time.Second)
+
+	log.Printf("Sent %d requests (%d transport errors), %d config reloads", sent.Load(), failed.Load(), reloads.Load())
+
+	races := 0
+	for _, p := range []*process{app, generator} {
+		for _, report := range p.races() {
+			races++
+			fmt.Fprintf(os.Stderr, "\n--- %s ---\n%s\n", p.name, report)
+		}
+	}
+	if races > 0 {
+		log.Printf("FAIL: %d data races detected", races)
+		os.Exit(1)
+	}
+	log.Printf("PASS: no data races detected")
+}
@@ -0,0 +1,187 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var badActorRequests = prometheus.NewCounterVec(
+	prometheus.CounterOpts{
+		Name: "traffic_generator_bad_actor_requests_total",
+		Help: "Requests sent by the bad actor persona, by attack and response status class",
+	},
+	[]string{"attack", "status_class"},
+)
+
+func init() {
+	prometheus.MustRegister(badActorRequests)
+}
+
+// Ataques disponibles para el persona "bad actor"
+var badActorAttacks = map[string]func(*http.Client, string) []int{
+	"credential_stuffing": credentialStuffing,
+	"scraping":            scrapingBurst,
+	"invalid_payload":     invalidPayloads,
+	"path_fuzzing":        pathFuzzing,
+}
+
+// User-Agents típicos de herramientas automatizadas
+var badUserAgents = []string{
+	"sqlmap/1.7.2#stable (https://sqlmap.org)",
+	"python-requests/2.31.0",
+	"curl/7.88.1",
+	"Mozilla/5.0 (compatible; Scrapy/2.11; +https://scrapy.org)",
+	"Go-http-client/1.1",
+	"",
+}
+
+var fuzzPaths = []string{
+	"/.env",
+	"/.git/config",
+	"/wp-login.php",
+	"/phpmyadmin/index.php",
+	"/admin/../../etc/passwd",
+	"/cgi-bin/test.cgi",
+	"/data?id=1%27%20OR%20%271%27=%271",
+	"/server-status",
+	"/actuator/env",
+	"/api/v1/users/../../admin",
+}
+
+var stuffedCredentials = []string{"admin:admin", "root:123456", "user@example.com:password1", "test:test", "admin:letmein"}
+
+// badActorAttackList parsea BAD_ACTOR_ATTACKS (vacío = todos).
+func badActorAttackList(value string) ([]string, error) {
+	if value == "" {
+		var all []string
+		for name := range badActorAttacks {
+			all = append(all, name)
+		}
+		return all, nil
+	}
+
+	var attacks []string
+	for _, name := range strings.Split(value, ",") {
+		name = strings.TrimSpace(name)
+		if _, ok := badActorAttacks[name]; !ok {
+			return nil, fmt.Errorf("unknown bad actor attack %q", name)
+		}
+		attacks = append(attacks, name)
+	}
+	return attacks, nil
+}
+
+// runBadActor lanza ataques a un ritmo bajo (BAD_ACTOR_RATE por minuto en
+// promedio, con llegadas de Poisson) mezclados con el tráfico normal.
+func runBadActor(client *http.Client, url string, rate float64, attacks []string) {
+	mean := time.Duration(float64(time.Minute) / rate)
+
+	for {
+		time.Sleep(time.Duration(rand.ExpFloat64() * float64(mean)))
+
+		attack := attacks[rand.Intn(len(attacks))]
+		statuses := badActorAttacks[attack](client, url)
+
+		rejected := 0
+		for _, code := range statuses {
+			if code >= 400 || code == 0 {
+				rejected++
+			}
+		}
+
+		logEntry := map[string]interface{}{
+			"timestamp": time.Now().Format(time.RFC3339),
+			"level":     "info",
+			"service":   "app1-traffic-generator",
+			"message":   fmt.Sprintf("Bad actor %s: %d requests, %d rejected", attack, len(statuses), rejected),
+			"persona":   "bad_actor",
+			"attack":    attack,
+			"requests":  len(statuses),
+			"rejected":  rejected,
+		}
+
+		logJSON, _ := json.Marshal(logEntry)
+		fmt.Println(string(logJSON))
+	}
+}
+
+// badRequest envía una request con un User-Agent de herramienta y sin los
+// headers que manda un navegador. El endpoint de las métricas de fase es el
+// ataque, para no abrir una serie por cada path fuzzeado.
+func badRequest(client *http.Client, attack, method, url, contentType string, body []byte) int {
+	req, err := http.NewRequest(method, url, bytes.NewReader(body))
+	if err != nil {
+		return 0
+	}
+	req.Header.Set("User-Agent", badUserAgents[rand.Intn(len(badUserAgents))])
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+
+	statusCode, err := doRequest(client, req, "bad_actor:"+attack)
+	class := "error"
+	if err == nil {
+		class = strconv.Itoa(statusCode/100) + "xx"
+	}
+	badActorRequests.WithLabelValues(attack, class).Inc()
+
+	return statusCode
+}
+
+func credentialStuffing(client *http.Client, url string) []int {
+	var statuses []int
+	attempts := 3 + rand.Intn(5)
+	for i := 0; i < attempts; i++ {
+		user, pass, _ := strings.Cut(stuffedCredentials[rand.Intn(len(stuffedCredentials))], ":")
+		body, _ := json.Marshal(map[string]string{"username": user, "password": pass})
+		statuses = append(statuses, badRequest(client, "credential_stuffing", http.MethodPost, url+"/login", "application/json", body))
+		time.Sleep(time.Duration(50+rand.Intn(200)) * time.Millisecond)
+	}
+	return statuses
+}
+
+func scrapingBurst(client *http.Client, url string) []int {
+	var statuses []int
+	pages := 10 + rand.Intn(20)
+	for page := 1; page <= pages; page++ {
+		statuses = append(statuses, badRequest(client, "scraping", http.MethodGet, fmt.Sprintf("%s/data?page=%d", url, page), "", nil))
+		time.Sleep(time.Duration(10+rand.Intn(40)) * time.Millisecond)
+	}
+	return statuses
+}
+
+func invalidPayloads(client *http.Client, url string) []int {
+	payloads := []struct {
+		method, path, contentType string
+		body                      []byte
+	}{
+		{http.MethodPut, "/admin/tracing/sampling", "application/json", []byte(`{"ratio":`)},
+		{http.MethodPut, "/admin/tracing/sampling", "application/json", []byte(`{"ratio":"all"}`)},
+		{http.MethodPost, "/admin/alerts", "application/json", []byte(`<xml>not json</xml>`)},
+		{http.MethodPost, "/data", "text/plain", bytes.Repeat([]byte("A"), 64*1024)},
+	}
+
+	var statuses []int
+	for _, p := range payloads {
+		statuses = append(statuses, badRequest(client, "invalid_payload", p.method, url+p.path, p.contentType, p.body))
+	}
+	return statuses
+}
+
+func pathFuzzing(client *http.Client, url string) []int {
+	var statuses []int
+	probes := 3 + rand.Intn(5)
+	for i := 0; i < probes; i++ {
+		path := fuzzPaths[rand.Intn(len(fuzzPaths))]
+		statuses = append(statuses, badRequest(client, "path_fuzzing", http.MethodGet, url+path, "", nil))
+	}
+	return statuses
+}
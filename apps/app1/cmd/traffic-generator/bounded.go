@@ -0,0 +1,242 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"math"
+	"net/http"
+	"os"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// boundedResult es el resultado de una request del modo acotado.
+type boundedResult struct {
+	at      time.Duration // envío, desde el inicio de la ejecución
+	latency time.Duration
+	failed  bool
+}
+
+type boundedReport struct {
+	Scenario   string   `json:"scenario"`
+	Requests   int      `json:"requests"`
+	Errors     int      `json:"errors"`
+	ErrorRate  float64  `json:"error_rate"`
+	P50Ms      float64  `json:"p50_ms"`
+	P95Ms      float64  `json:"p95_ms"`
+	P99Ms      float64  `json:"p99_ms"`
+	DurationS  float64  `json:"duration_s"`
+	WarmupS    float64  `json:"warmup_s"`
+	Discarded  int      `json:"warmup_discarded"`
+	Steady     bool     `json:"steady_state"`
+	Passed     bool     `json:"passed"`
+	Violations []string `json:"violations,omitempty"`
+}
+
+// runBounded ejecuta el escenario durante --duration o --iterations y
+// termina con código 1 si se superan los umbrales, para usarlo en CI.
+func runBounded(args []string) {
+	fs := flag.NewFlagSet("run", flag.ExitOnError)
+	duration := fs.Duration("duration", 0, "stop after this long (e.g. 2m)")
+	iterations := fs.Int("iterations", 0, "stop after this many requests")
+	concurrency := fs.Int("concurrency", 5, "number of concurrent workers")
+	maxErrorRate := fs.Float64("max-error-rate", -1, "fail if the error rate (0-1) is above this value")
+	maxP95 := fs.Duration("max-p95", 0, "fail if the p95 latency is above this value (e.g. 500ms)")
+	warmup := fs.String("warmup", "", `discard results from the first N seconds (e.g. 10s), or "auto" to wait for steady-state latency`)
+	fs.Parse(args)
+
+	var warmupDuration time.Duration
+	if *warmup != "" && *warmup != "auto" {
+		parsed, err := time.ParseDuration(*warmup)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "run: invalid --warmup %q\n", *warmup)
+			os.Exit(2)
+		}
+		warmupDuration = parsed
+	}
+
+	if (*duration <= 0 && *iterations <= 0) || *concurrency <= 0 {
+		fmt.Fprintln(os.Stderr, "run: --duration or --iterations is required")
+		fs.Usage()
+		os.Exit(2)
+	}
+	if *duration > 0 && warmupDuration >= *duration {
+		fmt.Fprintln(os.Stderr, "run: --warmup must be shorter than --duration")
+		os.Exit(2)
+	}
+
+	config := loadConfig()
+	traffic := configuredScenario(config)
+
+	client := newHTTPClient(config)
+
+	var deadline time.Time
+	if *duration > 0 {
+		deadline = time.Now().Add(*duration)
+	}
+
+	var issued atomic.Int64
+	var mu sync.Mutex
+	var results []boundedResult
+	var wg sync.WaitGroup
+
+	start := time.Now()
+	for i := 0; i < *concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				if !deadline.IsZero() && time.Now().After(deadline) {
+					return
+				}
+				if *iterations > 0 && issued.Add(1) > int64(*iterations) {
+					return
+				}
+
+				// Se fecha al enviarla: una request lenta que sale durante
+				// el warmup se descarta aunque termine después
+				sent := time.Since(start)
+				result := timedRequest(client, config.TargetURL, traffic.pick())
+				result.at = sent
+
+				mu.Lock()
+				results = append(results, result)
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+
+	elapsed := time.Since(start)
+	sort.Slice(results, func(i, j int) bool { return results[i].at < results[j].at })
+
+	steady := true
+	if *warmup == "auto" {
+		warmupDuration, steady = detectSteadyState(results)
+	}
+	measured := results
+	for len(measured) > 0 && measured[0].at < warmupDuration {
+		measured = measured[1:]
+	}
+
+	report := buildReport(traffic.Name, measured, elapsed)
+	report.WarmupS = warmupDuration.Seconds()
+	report.Discarded = len(results) - len(measured)
+	report.Steady = steady
+	if len(measured) == 0 {
+		report.Violations = append(report.Violations, fmt.Sprintf("no requests left to measure after a %s warmup", warmupDuration))
+	}
+	if *maxErrorRate >= 0 && report.ErrorRate > *maxErrorRate {
+		report.Violations = append(report.Violations, fmt.Sprintf("error rate %.4f > %.4f", report.ErrorRate, *maxErrorRate))
+	}
+	if *maxP95 > 0 && report.P95Ms > float64(*maxP95)/float64(time.Millisecond) {
+		report.Violations = append(report.Violations, fmt.Sprintf("p95 %.1fms > %s", report.P95Ms, *maxP95))
+	}
+	report.Passed = len(report.Violations) == 0
+
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	enc.SetEscapeHTML(false)
+	enc.Encode(report)
+
+	if !report.Passed {
+		os.Exit(1)
+	}
+}
+
+func timedRequest(client *http.Client, url string, step scenarioStep) boundedResult {
+	req, err := http.NewRequest(step.Method, url+step.Path, nil)
+	if err != nil {
+		return boundedResult{failed: true}
+	}
+
+	start := time.Now()
+	statusCode, err := doRequest(client, req, step.Path)
+	if err != nil {
+		return boundedResult{latency: time.Since(start), failed: true}
+	}
+
+	return boundedResult{latency: time.Since(start), failed: statusCode >= 400}
+}
+
+func buildReport(name string, results []boundedResult, elapsed time.Duration) boundedReport {
+	report := boundedReport{Scenario: name, Requests: len(results), DurationS: elapsed.Seconds()}
+	if len(results) == 0 {
+		return report
+	}
+
+	latencies := make([]time.Duration, 0, len(results))
+	for _, r := range results {
+		if r.failed {
+			report.Errors++
+		}
+		latencies = append(latencies, r.latency)
+	}
+	sort.Slice(latencies, func(i, j int) bool { return latencies[i] < latencies[j] })
+
+	report.ErrorRate = float64(report.Errors) / float64(len(results))
+	report.P50Ms = percentileMs(latencies, 0.50)
+	report.P95Ms = percentileMs(latencies, 0.95)
+	report.P99Ms = percentileMs(latencies, 0.99)
+	return report
+}
+
+// percentileMs usa el método nearest-rank sobre latencias ordenadas.
+func percentileMs(sorted []time.Duration, p float64) float64 {
+	idx := int(math.Ceil(float64(len(sorted))*p)) - 1
+	if idx < 0 {
+		idx = 0
+	}
+	if idx >= len(sorted) {
+		idx = len(sorted) - 1
+	}
+	return float64(sorted[idx]) / float64(time.Millisecond)
+}
+
+const (
+	steadyWindowSize = 20   // requests por ventana
+	steadyWindows    = 3    // ventanas consecutivas estables requeridas
+	steadyMaxCV      = 0.10 // coeficiente de variación máximo entre ventanas
+)
+
+// detectSteadyState agrupa los resultados en ventanas de requests y busca
+// las primeras ventanas consecutivas cuya latencia media varía menos de
+// steadyMaxCV. Devuelve el instante en que empieza ese tramo estable; si no
+// se alcanza, no descarta nada y reporta que no hubo estado estable.
+func detectSteadyState(results []boundedResult) (time.Duration, bool) {
+	var means []float64
+	var starts []time.Duration
+	for i := 0; i+steadyWindowSize <= len(results); i += steadyWindowSize {
+		sum := 0.0
+		for _, r := range results[i : i+steadyWindowSize] {
+			sum += float64(r.latency)
+		}
+		means = append(means, sum/steadyWindowSize)
+		starts = append(starts, results[i].at)
+	}
+
+	for i := 0; i+steadyWindows <= len(means); i++ {
+		window := means[i : i+steadyWindows]
+
+		mean := 0.0
+		for _, m := range window {
+			mean += m
+		}
+		mean /= steadyWindows
+
+		variance := 0.0
+		for _, m := range window {
+			variance += (m - mean) * (m - mean)
+		}
+		variance /= steadyWindows
+
+		if mean > 0 && math.Sqrt(variance)/mean < steadyMaxCV {
+			return starts[i], true
+		}
+	}
+
+	return 0, false
+}
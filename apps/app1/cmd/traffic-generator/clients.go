@@ -0,0 +1,46 @@
+package main
+
+import (
+	"fmt"
+	"math/rand"
+)
+
+// clientCountry es un rango simulado de IPs de un país. Los rangos coinciden
+// con la tabla GeoIP por defecto de app1 (100.64.0.0/10, espacio compartido).
+type clientCountry struct {
+	country string
+	octet   int // segundo octeto del /16 dentro de 100.64.0.0/10
+	weight  float64
+}
+
+// Distribución de usuarios simulados, con mayoría en Latinoamérica
+var clientCountries = []clientCountry{
+	{"AR", 64, 0.30},
+	{"BR", 65, 0.15},
+	{"US", 66, 0.25},
+	{"ES", 67, 0.10},
+	{"DE", 68, 0.08},
+	{"IN", 69, 0.06},
+	{"JP", 70, 0.04},
+	{"AU", 71, 0.02},
+}
+
+// simulatedClientIP elige un país según su peso y una IP al azar de su rango.
+func simulatedClientIP() string {
+	total := 0.0
+	for _, c := range clientCountries {
+		total += c.weight
+	}
+
+	r := rand.Float64() * total
+	chosen := clientCountries[len(clientCountries)-1]
+	for _, c := range clientCountries {
+		if r < c.weight {
+			chosen = c
+			break
+		}
+		r -= c.weight
+	}
+
+	return fmt.Sprintf("100.%d.%d.%d", chosen.octet, rand.Intn(256), 1+rand.Intn(254))
+}
@@ -0,0 +1,99 @@
+package main
+
+import (
+	"crypto/tls"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptrace"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// Latencia observada desde el cliente, separada por fase (httptrace)
+var requestPhaseDuration = prometheus.NewHistogramVec(
+	prometheus.HistogramOpts{
+		Name:    "traffic_generator_request_phase_seconds",
+		Help:    "Client-observed request latency broken down by phase (dns, connect, tls, ttfb, total)",
+		Buckets: prometheus.ExponentialBuckets(0.0005, 2, 16),
+	},
+	[]string{"endpoint", "phase"},
+)
+
+func init() {
+	prometheus.MustRegister(requestPhaseDuration)
+}
+
+// startMetricsServer expone /metrics del generador en segundo plano.
+func startMetricsServer(port string) {
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", promhttp.Handler())
+
+	go func() {
+		if err := http.ListenAndServe(":"+port, mux); err != nil {
+			log.Printf("Error serving metrics on port %s: %v", port, err)
+		}
+	}()
+}
+
+// doRequest ejecuta la request midiendo DNS, conexión, TLS, tiempo hasta
+// el primer byte y total (incluyendo la lectura del body). Las fases de
+// DNS/conexión/TLS solo se registran cuando no se reutiliza una conexión.
+func doRequest(client *http.Client, req *http.Request, endpoint string) (int, error) {
+	var dnsStart, tlsStart time.Time
+	start := time.Now()
+
+	// Con happy eyeballs puede haber varios dials en paralelo para la misma
+	// request; cada uno se mide desde su propio inicio
+	var connectMu sync.Mutex
+	connectStarts := make(map[string]time.Time)
+
+	observe := func(phase string, since time.Time) {
+		requestPhaseDuration.WithLabelValues(endpoint, phase).Observe(time.Since(since).Seconds())
+	}
+
+	trace := &httptrace.ClientTrace{
+		DNSStart: func(httptrace.DNSStartInfo) { dnsStart = time.Now() },
+		DNSDone: func(httptrace.DNSDoneInfo) {
+			if !dnsStart.IsZero() {
+				observe("dns", dnsStart)
+			}
+		},
+		ConnectStart: func(network, addr string) {
+			connectMu.Lock()
+			connectStarts[network+"/"+addr] = time.Now()
+			connectMu.Unlock()
+		},
+		ConnectDone: func(network, addr string, err error) {
+			connectMu.Lock()
+			connectStart, ok := connectStarts[network+"/"+addr]
+			delete(connectStarts, network+"/"+addr)
+			connectMu.Unlock()
+
+			if err == nil && ok {
+				observe("connect", connectStart)
+			}
+		},
+		TLSHandshakeStart: func() { tlsStart = time.Now() },
+		TLSHandshakeDone: func(_ tls.ConnectionState, err error) {
+			if err == nil && !tlsStart.IsZero() {
+				observe("tls", tlsStart)
+			}
+		},
+		GotFirstResponseByte: func() { observe("ttfb", start) },
+	}
+
+	resp, err := client.Do(req.WithContext(httptrace.WithClientTrace(req.Context(), trace)))
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	io.Copy(io.Discard, resp.Body)
+	observe("total", start)
+
+	return resp.StatusCode, nil
+}
@@ -0,0 +1,165 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// accessRecord es una línea del access log que se puede reproducir. Las
+// líneas sin path (logs de aplicación) se ignoran.
+type accessRecord struct {
+	Timestamp string `json:"timestamp"`
+	Method    string `json:"method"`
+	Route     string `json:"route"`
+	Path      string `json:"path"`
+}
+
+type replayRequest struct {
+	ts     time.Time
+	at     time.Duration
+	method string
+	path   string
+	// Label de endpoint: el patrón de ruta del access log de app1, o
+	// "replay" si la línea no lo trae. El path crudo (ids, fuzzing) tendría
+	// cardinalidad sin límite.
+	endpoint string
+}
+
+func loadReplayFile(path string) ([]replayRequest, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var requests []replayRequest
+
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for line := 1; scanner.Scan(); line++ {
+		var record accessRecord
+		if err := json.Unmarshal(scanner.Bytes(), &record); err != nil || record.Path == "" {
+			continue
+		}
+
+		ts, err := time.Parse(time.RFC3339Nano, record.Timestamp)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: invalid timestamp %q", line, record.Timestamp)
+		}
+
+		method := strings.ToUpper(record.Method)
+		if method == "" {
+			method = http.MethodGet
+		}
+
+		endpoint := record.Route
+		if endpoint == "" {
+			endpoint = "replay"
+		}
+
+		requests = append(requests, replayRequest{ts: ts, method: method, path: record.Path, endpoint: endpoint})
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	// app1 fecha cada línea al empezar la request pero la escribe al
+	// terminar, así que el archivo no viene ordenado: se ordena y el tiempo
+	// relativo se mide desde la más temprana.
+	sort.SliceStable(requests, func(i, j int) bool { return requests[i].ts.Before(requests[j].ts) })
+	for i := range requests {
+		requests[i].at = requests[i].ts.Sub(requests[0].ts)
+	}
+	return requests, nil
+}
+
+// runReplay reenvía las requests de un access log NDJSON respetando el
+// tiempo relativo entre ellas, acelerado o frenado por --speed.
+func runReplay(args []string) {
+	fs := flag.NewFlagSet("replay", flag.ExitOnError)
+	file := fs.String("file", "", "NDJSON access log to replay")
+	speed := fs.Float64("speed", 1, "replay speed multiplier (2 = twice as fast)")
+	fs.Parse(args)
+
+	if *file == "" || *speed <= 0 {
+		fs.Usage()
+		os.Exit(2)
+	}
+
+	config := loadConfig()
+	startMetricsServer(config.MetricsPort)
+
+	requests, err := loadReplayFile(*file)
+	if err != nil {
+		log.Fatalf("Error loading replay file: %v", err)
+	}
+
+	logEntry := map[string]interface{}{
+		"timestamp":  time.Now().Format(time.RFC3339),
+		"level":      "info",
+		"service":    "app1-traffic-generator",
+		"message":    fmt.Sprintf("Replaying %d requests from %s at %gx", len(requests), *file, *speed),
+		"target_url": config.TargetURL,
+	}
+
+	logJSON, _ := json.Marshal(logEntry)
+	fmt.Println(string(logJSON))
+
+	client := newHTTPClient(config)
+
+	start := time.Now()
+	var wg sync.WaitGroup
+	for _, req := range requests {
+		due := start.Add(time.Duration(float64(req.at) / *speed))
+		time.Sleep(time.Until(due))
+
+		wg.Add(1)
+		go func(req replayRequest) {
+			defer wg.Done()
+			replayOne(client, config.TargetURL, req)
+		}(req)
+	}
+	wg.Wait()
+}
+
+func replayOne(client *http.Client, url string, req replayRequest) {
+	httpReq, err := http.NewRequest(req.method, url+req.path, nil)
+	if err != nil {
+		log.Printf("Error building replay request %s %s: %v", req.method, req.path, err)
+		return
+	}
+
+	statusCode, err := doRequest(client, httpReq, req.endpoint)
+	if err != nil {
+		log.Printf("Error making request to %s%s: %v", url, req.path, err)
+		return
+	}
+
+	status := "success"
+	if statusCode >= 400 {
+		status = "error"
+	}
+
+	logEntry := map[string]interface{}{
+		"timestamp": time.Now().Format(time.RFC3339),
+		"level":     "info",
+		"service":   "app1-traffic-generator",
+		"message":   fmt.Sprintf("Replayed %s %s - Status: %d", req.method, req.path, statusCode),
+		"endpoint":  req.path,
+		"method":    req.method,
+		"status":    status,
+		"mode":      "replay",
+	}
+
+	logJSON, _ := json.Marshal(logEntry)
+	fmt.Println(string(logJSON))
+}
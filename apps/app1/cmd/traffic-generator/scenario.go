@@ -0,0 +1,222 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"math/rand"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// scenarioStep es una request del escenario con su peso relativo.
+type scenarioStep struct {
+	Method string  `json:"method"`
+	Path   string  `json:"path"`
+	Weight float64 `json:"weight"`
+}
+
+type scenario struct {
+	Name  string         `json:"name"`
+	Steps []scenarioStep `json:"steps"`
+}
+
+// Escenario por defecto: el mix histórico de endpoints de app1
+var defaultScenario = scenario{
+	Name: "default",
+	Steps: []scenarioStep{
+		{Method: http.MethodGet, Path: "/health", Weight: 0.5},
+		{Method: http.MethodGet, Path: "/data", Weight: 0.4},
+		{Method: http.MethodGet, Path: "/slow", Weight: 0.1},
+	},
+}
+
+// pick elige un paso según los pesos relativos.
+func (s scenario) pick() scenarioStep {
+	total := 0.0
+	for _, step := range s.Steps {
+		total += step.Weight
+	}
+
+	r := rand.Float64() * total
+	for _, step := range s.Steps {
+		if r < step.Weight {
+			return step
+		}
+		r -= step.Weight
+	}
+	return s.Steps[len(s.Steps)-1]
+}
+
+// configuredScenario devuelve el escenario de SCENARIO_FILE o el default.
+func configuredScenario(config TrafficConfig) scenario {
+	if config.ScenarioFile == "" {
+		return defaultScenario
+	}
+
+	s, err := loadScenario(config.ScenarioFile)
+	if err != nil {
+		log.Fatalf("Error loading scenario: %v", err)
+	}
+	return s
+}
+
+// loadScenario carga un escenario según la extensión del archivo: .har
+// (export del navegador), .js (subconjunto de k6) o .json (formato interno).
+func loadScenario(path string) (scenario, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return scenario{}, err
+	}
+
+	var s scenario
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".har":
+		s, err = scenarioFromHAR(data)
+	case ".js":
+		s, err = scenarioFromK6(data)
+	default:
+		err = json.Unmarshal(data, &s)
+	}
+	if err != nil {
+		return scenario{}, fmt.Errorf("%s: %w", path, err)
+	}
+	if len(s.Steps) == 0 {
+		return scenario{}, fmt.Errorf("%s: scenario has no requests", path)
+	}
+	if s.Name == "" {
+		s.Name = filepath.Base(path)
+	}
+
+	return s, nil
+}
+
+type harFile struct {
+	Log struct {
+		Entries []struct {
+			Request struct {
+				Method string `json:"method"`
+				URL    string `json:"url"`
+			} `json:"request"`
+		} `json:"entries"`
+	} `json:"log"`
+}
+
+func scenarioFromHAR(data []byte) (scenario, error) {
+	var har harFile
+	if err := json.Unmarshal(data, &har); err != nil {
+		return scenario{}, err
+	}
+
+	counts := newStepCounter()
+	for _, entry := range har.Log.Entries {
+		u, err := url.Parse(entry.Request.URL)
+		if err != nil {
+			continue
+		}
+		counts.add(entry.Request.Method, u.RequestURI())
+	}
+
+	return scenario{Steps: counts.steps()}, nil
+}
+
+var (
+	// http.get('url'), http.del("url"), ...
+	k6ShortcutCall = regexp.MustCompile("http\\.(get|post|put|patch|del|head|options)\\(\\s*[`'\"]([^`'\"]+)[`'\"]")
+	// http.request('METHOD', 'url')
+	k6RequestCall = regexp.MustCompile("http\\.request\\(\\s*[`'\"]([A-Za-z]+)[`'\"]\\s*,\\s*[`'\"]([^`'\"]+)[`'\"]")
+)
+
+// scenarioFromK6 reconoce las llamadas http.* con URL literal de un script
+// k6; el resto del script (checks, sleeps, lógica) se ignora.
+func scenarioFromK6(data []byte) (scenario, error) {
+	counts := newStepCounter()
+
+	for _, m := range k6ShortcutCall.FindAllStringSubmatch(string(data), -1) {
+		method := m[1]
+		if method == "del" {
+			method = http.MethodDelete
+		}
+		counts.add(method, k6Path(m[2]))
+	}
+	for _, m := range k6RequestCall.FindAllStringSubmatch(string(data), -1) {
+		counts.add(m[1], k6Path(m[2]))
+	}
+
+	if len(counts.order) == 0 {
+		return scenario{}, errors.New("no http.* calls with literal URLs found")
+	}
+	return scenario{Steps: counts.steps()}, nil
+}
+
+// k6Path quita el host o un prefijo ${BASE_URL} de la URL.
+func k6Path(raw string) string {
+	if i := strings.Index(raw, "}"); strings.HasPrefix(raw, "${") && i >= 0 {
+		raw = raw[i+1:]
+	}
+	if u, err := url.Parse(raw); err == nil && u.Host != "" {
+		return u.RequestURI()
+	}
+	if !strings.HasPrefix(raw, "/") {
+		raw = "/" + raw
+	}
+	return raw
+}
+
+// stepCounter agrupa requests repetidas; el peso es la cantidad de veces
+// que aparecen.
+type stepCounter struct {
+	order  []string
+	counts map[string]*scenarioStep
+}
+
+func newStepCounter() *stepCounter {
+	return &stepCounter{counts: map[string]*scenarioStep{}}
+}
+
+func (c *stepCounter) add(method, path string) {
+	method = strings.ToUpper(method)
+	key := method + " " + path
+	if step, ok := c.counts[key]; ok {
+		step.Weight++
+		return
+	}
+	c.order = append(c.order, key)
+	c.counts[key] = &scenarioStep{Method: method, Path: path, Weight: 1}
+}
+
+func (c *stepCounter) steps() []scenarioStep {
+	steps := make([]scenarioStep, 0, len(c.order))
+	for _, key := range c.order {
+		steps = append(steps, *c.counts[key])
+	}
+	return steps
+}
+
+// runConvert imprime en formato interno un escenario importado, para
+// revisarlo o editarlo antes de usarlo con SCENARIO_FILE.
+func runConvert(args []string) {
+	fs := flag.NewFlagSet("convert", flag.ExitOnError)
+	file := fs.String("file", "", "HAR (.har), k6 script (.js) or scenario (.json) to convert")
+	fs.Parse(args)
+
+	if *file == "" {
+		fs.Usage()
+		os.Exit(2)
+	}
+
+	s, err := loadScenario(*file)
+	if err != nil {
+		log.Fatalf("Error loading scenario: %v", err)
+	}
+
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	enc.Encode(s)
+}
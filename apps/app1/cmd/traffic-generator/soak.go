@@ -0,0 +1,325 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"math"
+	"net/http"
+	"os"
+	"os/signal"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"time"
+
+	dto "github.com/prometheus/client_model/go"
+	"github.com/prometheus/common/expfmt"
+)
+
+// Métricas de proceso que se siguen durante el soak. Si el target no expone
+// alguna (app2 no tiene go_*), se omite del reporte.
+var soakMetrics = []string{
+	"process_resident_memory_bytes",
+	"go_memstats_heap_inuse_bytes",
+	"go_goroutines",
+	"process_open_fds",
+}
+
+const (
+	soakMinSamples = 5   // snapshots mínimos después del warmup para opinar
+	soakMinR2      = 0.6 // qué tan lineal tiene que ser el crecimiento
+)
+
+type soakSnapshot struct {
+	at     time.Duration
+	values map[string]float64
+}
+
+type soakTrend struct {
+	Metric       string  `json:"metric"`
+	Samples      int     `json:"samples"`
+	First        float64 `json:"first"`
+	Last         float64 `json:"last"`
+	Min          float64 `json:"min"`
+	Max          float64 `json:"max"`
+	SlopePerHour float64 `json:"slope_per_hour"`
+	R2           float64 `json:"r2"`
+	Growth       float64 `json:"growth"`
+	Leak         bool    `json:"suspected_leak"`
+}
+
+type soakReport struct {
+	Target          string      `json:"target"`
+	DurationS       float64     `json:"duration_s"`
+	IntervalS       float64     `json:"interval_s"`
+	WarmupS         float64     `json:"warmup_s"`
+	Requests        int64       `json:"requests"`
+	Errors          int64       `json:"errors"`
+	Snapshots       int         `json:"snapshots"`
+	FailedSnapshots int         `json:"failed_snapshots"`
+	Trends          []soakTrend `json:"trends"`
+	Leaks           []string    `json:"suspected_leaks,omitempty"`
+	Passed          bool        `json:"passed"`
+}
+
+// runSoak sostiene una carga moderada durante horas, toma snapshots de las
+// métricas de proceso del target cada --interval y al final ajusta una
+// recta a cada serie: un crecimiento sostenido y lineal por encima de
+// --max-growth se reporta como posible leak y termina con código 1.
+func runSoak(args []string) {
+	fs := flag.NewFlagSet("soak", flag.ExitOnError)
+	duration := fs.Duration("duration", 0, "how long to soak the target (e.g. 4h)")
+	interval := fs.Duration("interval", time.Minute, "time between /metrics snapshots")
+	warmup := fs.Duration("warmup", 5*time.Minute, "snapshots taken before this are left out of the trends")
+	concurrency := fs.Int("concurrency", 2, "number of concurrent workers")
+	thinkTime := fs.Duration("think-time", 200*time.Millisecond, "pause between requests of each worker")
+	maxGrowth := fs.Float64("max-growth", 0.2, "flag a leak when a metric grows more than this fraction (0.2 = 20%) over the soak")
+	metricsURL := fs.String("metrics-url", "", "target metrics endpoint (default TARGET_URL/metrics)")
+	fs.Parse(args)
+
+	if *duration <= 0 || *interval <= 0 || *concurrency <= 0 {
+		fmt.Fprintln(os.Stderr, "soak: --duration is required")
+		fs.Usage()
+		os.Exit(2)
+	}
+	if *warmup >= *duration {
+		fmt.Fprintln(os.Stderr, "soak: --warmup must be shorter than --duration")
+		os.Exit(2)
+	}
+
+	config := loadConfig()
+	traffic := configuredScenario(config)
+	if *metricsURL == "" {
+		*metricsURL = config.TargetURL + "/metrics"
+	}
+
+	client := newHTTPClient(config)
+	scraper := newBaseClient(config)
+
+	// Ctrl+C corta antes pero igual imprime el reporte con lo que haya
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	ctx, cancel := context.WithTimeout(ctx, *duration)
+	defer cancel()
+
+	var requests, errors atomic.Int64
+	var wg sync.WaitGroup
+	for i := 0; i < *concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for ctx.Err() == nil {
+				result := timedRequest(client, config.TargetURL, traffic.pick())
+				requests.Add(1)
+				if result.failed {
+					errors.Add(1)
+				}
+
+				select {
+				case <-ctx.Done():
+				case <-time.After(*thinkTime):
+				}
+			}
+		}()
+	}
+
+	start := time.Now()
+	var snapshots []soakSnapshot
+	failed := 0
+
+	take := func() {
+		values, err := scrapeProcessMetrics(scraper, *metricsURL)
+		if err != nil {
+			failed++
+			soakLog("warn", "Snapshot failed: "+err.Error(), nil)
+			return
+		}
+		at := time.Since(start)
+		snapshots = append(snapshots, soakSnapshot{at: at, values: values})
+
+		fields := map[string]interface{}{"elapsed": at.Round(time.Second).String()}
+		for name, v := range values {
+			fields[name] = v
+		}
+		soakLog("info", fmt.Sprintf("Soak snapshot %d", len(snapshots)), fields)
+	}
+
+	take()
+	ticker := time.NewTicker(*interval)
+	for running := true; running; {
+		select {
+		case <-ctx.Done():
+			running = false
+		case <-ticker.C:
+			take()
+		}
+	}
+	ticker.Stop()
+	wg.Wait()
+	take()
+
+	report := soakReport{
+		Target:          *metricsURL,
+		DurationS:       time.Since(start).Seconds(),
+		IntervalS:       interval.Seconds(),
+		WarmupS:         warmup.Seconds(),
+		Requests:        requests.Load(),
+		Errors:          errors.Load(),
+		Snapshots:       len(snapshots),
+		FailedSnapshots: failed,
+	}
+
+	measured := snapshots
+	for len(measured) > 0 && measured[0].at < *warmup {
+		measured = measured[1:]
+	}
+	for _, name := range soakMetrics {
+		trend, ok := buildTrend(name, measured, *maxGrowth)
+		if !ok {
+			continue
+		}
+		report.Trends = append(report.Trends, trend)
+		if trend.Leak {
+			report.Leaks = append(report.Leaks, fmt.Sprintf("%s grew %.0f%% (%+.3g/h, r2=%.2f)", name, trend.Growth*100, trend.SlopePerHour, trend.R2))
+		}
+	}
+	report.Passed = len(report.Leaks) == 0
+
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	enc.SetEscapeHTML(false)
+	enc.Encode(report)
+
+	if !report.Passed {
+		os.Exit(1)
+	}
+}
+
+// scrapeProcessMetrics lee /metrics del target y devuelve las soakMetrics
+// presentes, sumando las series si una métrica trae labels.
+func scrapeProcessMetrics(client *http.Client, metricsURL string) (map[string]float64, error) {
+	req, err := http.NewRequest(http.MethodGet, metricsURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "text/plain")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("metrics endpoint returned status %d", resp.StatusCode)
+	}
+
+	var parser expfmt.TextParser
+	families, err := parser.TextToMetricFamilies(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("parsing metrics: %w", err)
+	}
+
+	values := make(map[string]float64)
+	for _, name := range soakMetrics {
+		family, ok := families[name]
+		if !ok {
+			continue
+		}
+		total := 0.0
+		for _, m := range family.GetMetric() {
+			total += metricValue(m)
+		}
+		values[name] = total
+	}
+	return values, nil
+}
+
+func metricValue(m *dto.Metric) float64 {
+	switch {
+	case m.GetGauge() != nil:
+		return m.GetGauge().GetValue()
+	case m.GetCounter() != nil:
+		return m.GetCounter().GetValue()
+	default:
+		return m.GetUntyped().GetValue()
+	}
+}
+
+// buildTrend ajusta una recta por mínimos cuadrados a la serie. Growth es lo
+// que crece la recta en todo el tramo relativo a su valor inicial; solo
+// cuenta como leak si además la serie es bastante lineal (r2), para no
+// confundir ruido o un escalón con una pérdida sostenida.
+func buildTrend(name string, snapshots []soakSnapshot, maxGrowth float64) (soakTrend, bool) {
+	var xs, ys []float64
+	for _, s := range snapshots {
+		if v, ok := s.values[name]; ok {
+			xs = append(xs, s.at.Hours())
+			ys = append(ys, v)
+		}
+	}
+	if len(ys) == 0 {
+		return soakTrend{}, false
+	}
+
+	trend := soakTrend{Metric: name, Samples: len(ys), First: ys[0], Last: ys[len(ys)-1], Min: ys[0], Max: ys[0]}
+	for _, y := range ys {
+		trend.Min = math.Min(trend.Min, y)
+		trend.Max = math.Max(trend.Max, y)
+	}
+	if len(ys) < 2 {
+		return trend, true
+	}
+
+	n := float64(len(ys))
+	var sumX, sumY float64
+	for i := range xs {
+		sumX += xs[i]
+		sumY += ys[i]
+	}
+	meanX, meanY := sumX/n, sumY/n
+
+	var sxx, sxy, syy float64
+	for i := range xs {
+		dx, dy := xs[i]-meanX, ys[i]-meanY
+		sxx += dx * dx
+		sxy += dx * dy
+		syy += dy * dy
+	}
+	if sxx == 0 {
+		return trend, true
+	}
+
+	trend.SlopePerHour = sxy / sxx
+	if syy > 0 {
+		trend.R2 = sxy * sxy / (sxx * syy)
+	}
+
+	initial := meanY - trend.SlopePerHour*(meanX-xs[0])
+	if initial > 0 {
+		trend.Growth = trend.SlopePerHour * (xs[len(xs)-1] - xs[0]) / initial
+	}
+
+	trend.Leak = len(ys) >= soakMinSamples && trend.SlopePerHour > 0 &&
+		trend.R2 >= soakMinR2 && trend.Growth > maxGrowth
+	return trend, true
+}
+
+// soakLog escribe el progreso en stderr para dejar stdout al reporte.
+func soakLog(level, message string, fields map[string]interface{}) {
+	entry := map[string]interface{}{
+		"timestamp": time.Now().Format(time.RFC3339),
+		"level":     level,
+		"service":   "app1-traffic-generator",
+		"message":   message,
+		"mode":      "soak",
+	}
+	for k, v := range fields {
+		entry[k] = v
+	}
+
+	logJSON, _ := json.Marshal(entry)
+	fmt.Fprintln(os.Stderr, string(logJSON))
+}
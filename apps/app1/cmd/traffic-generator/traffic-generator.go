@@ -1,19 +1,29 @@
 package main
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"log"
 	"math/rand"
+	"net"
 	"net/http"
 	"os"
+	"strconv"
 	"time"
 )
 
 type TrafficConfig struct {
 	TargetURL       string `json:"target_url"`
+	MetricsPort     string `json:"metrics_port"`
 	RequestInterval int    `json:"request_interval_seconds"`
 	ErrorRate       float32 `json:"error_rate"`
+	ScenarioFile    string  `json:"scenario_file"`
+	TargetSocket    string  `json:"target_socket"`
+	APIKey          string  `json:"-"`
+	BadActorRate    float64 `json:"bad_actor_rate_per_minute"`
+	BadActorAttacks string  `json:"bad_actor_attacks"`
+	SimulateClients bool    `json:"simulate_client_ips"`
 }
 
 func loadConfig() TrafficConfig {
@@ -27,23 +37,106 @@ func loadConfig() TrafficConfig {
 		config.TargetURL = url
 	}
 	
+	config.ScenarioFile = os.Getenv("SCENARIO_FILE")
+	config.TargetSocket = os.Getenv("TARGET_SOCKET")
+	config.APIKey = os.Getenv("API_KEY")
+	config.BadActorAttacks = os.Getenv("BAD_ACTOR_ATTACKS")
+	config.SimulateClients = os.Getenv("SIMULATE_CLIENT_IPS") == "true"
+	
+	if rate, err := strconv.ParseFloat(os.Getenv("BAD_ACTOR_RATE"), 64); err == nil && rate > 0 {
+		config.BadActorRate = rate
+	}
+	
+	config.MetricsPort = "8081"
+	if port := os.Getenv("METRICS_PORT"); port != "" {
+		config.MetricsPort = port
+	}
+	
 	return config
 }
 
-func makeRequest(url string, endpoint string) {
+// newHTTPClient crea el cliente que simula usuarios: además de la conexión
+// de newBaseClient envía los headers de un navegador y X-API-Key si hay.
+func newHTTPClient(config TrafficConfig) *http.Client {
+	client := newBaseClient(config)
+	
+	headers := http.Header{}
+	headers.Set("User-Agent", "Mozilla/5.0 (X11; Linux x86_64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/124.0 Safari/537.36")
+	headers.Set("Accept", "application/json")
+	headers.Set("Accept-Language", "es-AR,es;q=0.9,en;q=0.8")
+	if config.APIKey != "" {
+		headers.Set("X-API-Key", config.APIKey)
+	}
+	
+	transport := headerTransport{base: client.Transport, headers: headers}
+	if config.SimulateClients {
+		transport.forwardedFor = simulatedClientIP
+	}
+	
+	client.Transport = transport
+	return client
+}
+
+// newBaseClient crea el cliente hacia el target. Con TARGET_SOCKET las
+// conexiones van por el socket unix y TARGET_URL solo aporta Host y path.
+func newBaseClient(config TrafficConfig) *http.Client {
 	client := &http.Client{
 		Timeout: 10 * time.Second,
 	}
 	
-	resp, err := client.Get(url + endpoint)
+	if config.TargetSocket != "" {
+		dialer := &net.Dialer{}
+		client.Transport = &http.Transport{
+			DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+				return dialer.DialContext(ctx, "unix", config.TargetSocket)
+			},
+		}
+	}
+	
+	return client
+}
+
+// headerTransport agrega headers fijos a cada request sin pisar los que ya trae.
+type headerTransport struct {
+	base    http.RoundTripper
+	headers http.Header
+	
+	// Si está definido, cada request lleva X-Forwarded-For con la IP que devuelve
+	forwardedFor func() string
+}
+
+func (t headerTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	base := t.base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	req = req.Clone(req.Context())
+	for key, values := range t.headers {
+		if _, ok := req.Header[key]; !ok {
+			req.Header[key] = values
+		}
+	}
+	if t.forwardedFor != nil && req.Header.Get("X-Forwarded-For") == "" {
+		req.Header.Set("X-Forwarded-For", t.forwardedFor())
+	}
+	return base.RoundTrip(req)
+}
+
+func makeRequest(client *http.Client, url string, method string, endpoint string) {
+	req, err := http.NewRequest(method, url+endpoint, nil)
+	if err != nil {
+		log.Printf("Error building request to %s%s: %v", url, endpoint, err)
+		return
+	}
+	
+	statusCode, err := doRequest(client, req, endpoint)
 	if err != nil {
 		log.Printf("Error making request to %s%s: %v", url, endpoint, err)
 		return
 	}
-	defer resp.Body.Close()
 	
 	status := "success"
-	if resp.StatusCode >= 400 {
+	if statusCode >= 400 {
 		status = "error"
 	}
 	
@@ -51,7 +144,7 @@ func makeRequest(url string, endpoint string) {
 		"timestamp": time.Now().Format(time.RFC3339),
 		"level":     "info",
 		"service":   "app1-traffic-generator",
-		"message":   fmt.Sprintf("Request to %s - Status: %d", endpoint, resp.StatusCode),
+		"message":   fmt.Sprintf("Request to %s - Status: %d", endpoint, statusCode),
 		"endpoint":  endpoint,
 		"status":    status,
 	}
@@ -63,8 +156,9 @@ func makeRequest(url string, endpoint string) {
 func generateTraffic() {
 	config := loadConfig()
 	
-	endpoints := []string{"/health", "/data", "/slow"}
-	weights := []float32{0.5, 0.4, 0.1} // Probabilidades relativas
+	traffic := configuredScenario(config)
+	client := newHTTPClient(config)
+	startMetricsServer(config.MetricsPort)
 	
 	logEntry := map[string]interface{}{
 		"timestamp":  time.Now().Format(time.RFC3339),
@@ -72,34 +166,35 @@ func generateTraffic() {
 		"service":    "app1-traffic-generator",
 		"message":    "Traffic generator started",
 		"target_url": config.TargetURL,
+		"scenario":   traffic.Name,
 	}
 	
 	logJSON, _ := json.Marshal(logEntry)
 	fmt.Println(string(logJSON))
 	
+	// Persona malicioso opcional, en paralelo al tráfico normal
+	if config.BadActorRate > 0 {
+		attacks, err := badActorAttackList(config.BadActorAttacks)
+		if err != nil {
+			log.Fatalf("Error configuring bad actor: %v", err)
+		}
+		go runBadActor(newBaseClient(config), config.TargetURL, config.BadActorRate, attacks)
+	}
+	
 	ticker := time.NewTicker(time.Duration(config.RequestInterval) * time.Second)
 	defer ticker.Stop()
 	
 	for {
 		select {
 		case <-ticker.C:
-			// Seleccionar endpoint basado en pesos
-			r := rand.Float32()
-			var endpoint string
-			
-			if r < weights[0] {
-				endpoint = endpoints[0]
-			} else if r < weights[0]+weights[1] {
-				endpoint = endpoints[1]
-			} else {
-				endpoint = endpoints[2]
-			}
+			// Seleccionar request del escenario basado en pesos
+			step := traffic.pick()
 			
 			// Generar múltiples requests para simular carga
 			numRequests := 1 + rand.Intn(3) // 1-3 requests
 			
 			for i := 0; i < numRequests; i++ {
-				go makeRequest(config.TargetURL, endpoint)
+				go makeRequest(client, config.TargetURL, step.Method, step.Path)
 				
 				// Pequeña pausa entre requests
 				if i < numRequests-1 {
@@ -114,5 +209,29 @@ func main() {
 	// Seed para randomización
 	rand.Seed(time.Now().UnixNano())
 	
+	// Subcomando replay: reproducir un access log capturado
+	if len(os.Args) > 1 && os.Args[1] == "replay" {
+		runReplay(os.Args[2:])
+		return
+	}
+	
+	// Subcomando convert: importar HAR o k6 al formato de escenario
+	if len(os.Args) > 1 && os.Args[1] == "convert" {
+		runConvert(os.Args[2:])
+		return
+	}
+	
+	// Subcomando run: ejecución acotada con umbrales para CI
+	if len(os.Args) > 1 && os.Args[1] == "run" {
+		runBounded(os.Args[2:])
+		return
+	}
+	
+	// Subcomando soak: carga sostenida con seguimiento de recursos del target
+	if len(os.Args) > 1 && os.Args[1] == "soak" {
+		runSoak(os.Args[2:])
+		return
+	}
+	
 	generateTraffic()
 }
\ No newline at end of file
@@ -10,6 +10,7 @@ import uvicorn
 from fastapi import FastAPI, HTTPException, Request
 from fastapi.responses import JSONResponse
 from prometheus_client import Counter, Histogram, Gauge, generate_latest, CONTENT_TYPE_LATEST
+from prometheus_client.core import CounterMetricFamily, GaugeMetricFamily, REGISTRY
 from starlette.responses import Response
 
 from opentelemetry import trace
@@ -69,6 +70,118 @@ app2_errors_total = Counter(
     ['type']
 )
 
+# Límites del contenedor e hilos del proceso, mismos nombres que app1 con
+# prefijo app2_. Las process_* (FDs, RSS, CPU) ya las exporta prometheus_client.
+CGROUP_ROOT = "/sys/fs/cgroup"
+CGROUP_UNLIMITED = 1 << 62
+
+def read_cgroup_value(path):
+    try:
+        with open(path) as f:
+            value = f.read().strip()
+    except OSError:
+        return None
+    if value == "max":
+        return CGROUP_UNLIMITED
+    try:
+        return float(value)
+    except ValueError:
+        return None
+
+def read_cgroup_key_values(path):
+    try:
+        with open(path) as f:
+            lines = f.read().splitlines()
+    except OSError:
+        return None
+    values = {}
+    for line in lines:
+        key, _, value = line.strip().partition(" ")
+        try:
+            values[key] = float(value)
+        except ValueError:
+            continue
+    return values
+
+def read_cgroup_stats(root=CGROUP_ROOT):
+    """Usa cgroup v2 si está montado y si no los controladores de v1."""
+    stats = {}
+    if os.path.exists(os.path.join(root, "cgroup.controllers")):
+        limit = read_cgroup_value(os.path.join(root, "memory.max"))
+        usage = read_cgroup_value(os.path.join(root, "memory.current"))
+        try:
+            with open(os.path.join(root, "cpu.max")) as f:
+                quota, period = f.read().split()
+            if quota != "max" and float(period) > 0:
+                stats["cpu_limit"] = float(quota) / float(period)
+        except (OSError, ValueError):
+            pass
+        cpu_stat = read_cgroup_key_values(os.path.join(root, "cpu.stat"))
+        if cpu_stat is not None and "nr_periods" in cpu_stat:
+            stats["periods"] = cpu_stat["nr_periods"]
+            stats["throttled_periods"] = cpu_stat.get("nr_throttled", 0)
+            stats["throttled_seconds"] = cpu_stat.get("throttled_usec", 0) / 1e6
+    else:
+        memory = os.path.join(root, "memory")
+        limit = read_cgroup_value(os.path.join(memory, "memory.limit_in_bytes"))
+        usage = read_cgroup_value(os.path.join(memory, "memory.usage_in_bytes"))
+        cpu = os.path.join(root, "cpu")
+        if not os.path.exists(cpu):
+            cpu = os.path.join(root, "cpu,cpuacct")
+        quota = read_cgroup_value(os.path.join(cpu, "cpu.cfs_quota_us"))
+        period = read_cgroup_value(os.path.join(cpu, "cpu.cfs_period_us"))
+        if quota and period and quota > 0 and period > 0:
+            stats["cpu_limit"] = quota / period
+        cpu_stat = read_cgroup_key_values(os.path.join(cpu, "cpu.stat"))
+        if cpu_stat is not None:
+            stats["periods"] = cpu_stat.get("nr_periods", 0)
+            stats["throttled_periods"] = cpu_stat.get("nr_throttled", 0)
+            stats["throttled_seconds"] = cpu_stat.get("throttled_time", 0) / 1e9
+
+    if limit is not None and limit < CGROUP_UNLIMITED:
+        stats["memory_limit"] = limit
+    if usage is not None:
+        stats["memory_usage"] = usage
+    return stats
+
+def process_threads():
+    try:
+        with open("/proc/self/status") as f:
+            for line in f:
+                if line.startswith("Threads:"):
+                    return int(line.split()[1])
+    except (OSError, ValueError):
+        pass
+    return None
+
+class ContainerCollector:
+    """Lee el cgroup en cada scrape; fuera de Linux no emite nada."""
+
+    def collect(self):
+        threads = process_threads()
+        if threads is not None:
+            yield GaugeMetricFamily("app2_process_threads", "OS threads of the app2 process", value=threads)
+
+        stats = read_cgroup_stats()
+        gauges = [
+            ("memory_limit", "app2_container_memory_limit_bytes", "Memory limit of the container cgroup (absent when unlimited)"),
+            ("memory_usage", "app2_container_memory_usage_bytes", "Memory charged to the container cgroup, page cache included"),
+            ("cpu_limit", "app2_container_cpu_limit_cores", "CPU quota of the container cgroup in cores (absent when unlimited)"),
+        ]
+        counters = [
+            ("periods", "app2_container_cpu_periods", "CFS enforcement periods elapsed for the container cgroup"),
+            ("throttled_periods", "app2_container_cpu_throttled_periods", "CFS periods in which the container cgroup was throttled"),
+            ("throttled_seconds", "app2_container_cpu_throttled_seconds", "Total time the container cgroup was throttled"),
+        ]
+        for key, name, documentation in gauges:
+            if key in stats:
+                yield GaugeMetricFamily(name, documentation, value=stats[key])
+        for key, name, documentation in counters:
+            if key in stats:
+                yield CounterMetricFamily(name, documentation, value=stats[key])
+
+REGISTRY.register(ContainerCollector())
+
 # Configurar OpenTelemetry
 def setup_tracing():
     tempo_endpoint = os.getenv("TEMPO_ENDPOINT", "http://tempo:4318/v1/traces")
@@ -236,6 +349,39 @@ async def database_operation():
             "query_time": round(db_time, 3)
         }
 
+# Dependencias declaradas, mismo esquema que /admin/topology de app1.
+# DEPENDENCIES: "nombre=protocolo:criticidad,..." (critical u optional)
+DEFAULT_DEPENDENCIES = "postgresql=postgresql:critical,external-service=http:optional,tempo=otlp-http:optional"
+
+def parse_dependencies(value):
+    dependencies = []
+    for entry in value.split(","):
+        name, _, spec = entry.strip().partition("=")
+        protocol, _, criticality = spec.partition(":")
+        criticality = criticality or "critical"
+        if not name or not protocol or criticality not in ("critical", "optional"):
+            raise ValueError(f"entry {entry!r}: expected name=protocol:criticality")
+        dependencies.append({"name": name, "protocol": protocol, "criticality": criticality})
+    return dependencies
+
+def load_dependencies():
+    try:
+        return parse_dependencies(os.getenv("DEPENDENCIES", DEFAULT_DEPENDENCIES))
+    except ValueError as e:
+        logger.warning(f"Invalid DEPENDENCIES, using defaults: {e}")
+        return parse_dependencies(DEFAULT_DEPENDENCIES)
+
+declared_dependencies = load_dependencies()
+
+@app.get("/admin/topology")
+async def topology():
+    return {
+        "service": "app2",
+        "version": "1.0.0",
+        "instance_id": os.getenv("HOSTNAME", ""),
+        "dependencies": declared_dependencies
+    }
+
 # Simulador de métricas en background
 import asyncio
 import threading